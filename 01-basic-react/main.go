@@ -2,15 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"regexp"
-	"strings"
 
-	"github.com/toumakido/reAct/lib/bedrock"
+	"github.com/toumakido/reAct/lib/backend"
+	"github.com/toumakido/reAct/lib/cassette"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/react"
 	"github.com/toumakido/reAct/lib/tools"
-	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
 )
 
 const systemPrompt = `You are a helpful assistant that can read files to answer questions.
@@ -28,8 +30,7 @@ When you have gathered all necessary information and can provide the final answe
 
 Final Answer: [Your complete answer to the user's question]
 
-Available Action:
-- ReadFile: Reads a file from the data directory. Input should be just the filename (e.g., "start.txt")
+{{tools}}
 
 Important:
 - Always start by reading "start.txt" to begin your investigation
@@ -37,116 +38,96 @@ Important:
 - Continue until you have enough information to answer the question
 - Use "Final Answer:" only when you are ready to give the complete answer`
 
-const maxIterations = 15
+const nativeSystemPrompt = `You are a helpful assistant that can read files to answer questions.
+
+- Always start by reading "start.txt" with the ReadFile tool to begin your investigation
+- Follow the clues in each file to find the next file to read
+- When you have enough information, reply without calling any tool. Start that reply with "Final Answer:" followed by your complete answer.`
+
+// stopSequence ends generation before the model writes its own Observation
+const stopSequence = "\nObservation:"
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run . \"Your question here\"")
+	backendFlags := backend.RegisterFlags(flag.CommandLine, "")
+	usageFlags := usage.RegisterFlags(flag.CommandLine)
+	historyFlags := react.RegisterFlags(flag.CommandLine)
+	toolMode := react.RegisterToolModeFlag(flag.CommandLine)
+	dataDir := flag.String("data", tools.DataDir("data", "01-basic-react/data"), "Directory the file tools can read")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatal("Usage: go run . [flags] \"Your question here\"")
 	}
 
-	question := os.Args[1]
-
-	ctx := context.Background()
+	question := flag.Arg(0)
 
-	client, err := bedrock.NewClient(ctx)
+	ledger, err := usageFlags.NewLedger()
 	if err != nil {
-		log.Fatalf("Failed to create Bedrock client: %v", err)
+		log.Fatalf("Failed to load price table: %v", err)
 	}
-
-	if err := runReActLoop(ctx, client, question); err != nil {
-		log.Fatalf("Error during ReAct loop: %v", err)
+	ctx := usage.WithLedger(context.Background(), ledger)
+	budget, err := usageFlags.Budget()
+	if err != nil {
+		log.Fatalf("Invalid budget: %v", err)
 	}
-}
+	ctx, cancel := usage.WithBudget(ctx, budget)
+	defer cancel()
 
-func runReActLoop(ctx context.Context, client *bedrock.Client, question string) error {
-	messages := []types.Message{
-		{
-			Role:    "user",
-			Content: question,
-		},
+	client, closeClient, err := cassette.FromEnv(func() (llm.LLM, error) {
+		return backendFlags.New(ctx, backend.Settings{StopSequences: []string{stopSequence}})
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM client: %v", err)
 	}
+	defer closeClient()
 
-	fmt.Println("=== Starting ReAct Agent ===")
-	fmt.Printf("Question: %s\n\n", question)
-
-	for i := 0; i < maxIterations; i++ {
-		fmt.Printf("--- Iteration %d ---\n", i+1)
-
-		result, err := client.InvokeModel(ctx, systemPrompt, messages)
-		if err != nil {
-			return fmt.Errorf("failed to invoke model: %w", err)
-		}
-
-		fmt.Println(result.Text)
-		fmt.Printf("\n[Token Usage] Input: %d, Output: %d, Total: %d\n\n",
-			result.InputTokens, result.OutputTokens, result.InputTokens+result.OutputTokens)
+	fsys, err := tools.OpenFS(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fsys.Close()
 
-		messages = append(messages, types.Message{
-			Role:    "assistant",
-			Content: result.Text,
+	// Summaries can use a cheaper model on the same backend
+	summarizer := client
+	if historyFlags.Summarize && historyFlags.SummarizeModel != "" {
+		model, closeSummarizer, err := cassette.FromEnv(func() (llm.LLM, error) {
+			return backendFlags.WithModel(historyFlags.SummarizeModel).New(ctx, backend.Settings{})
 		})
-
-		if strings.Contains(result.Text, "Final Answer:") {
-			fmt.Println("=== Agent Complete ===")
-			return nil
-		}
-
-		action, actionInput, found := parseAction(result.Text)
-		if !found {
-			continue
-		}
-
-		if action != "ReadFile" {
-			observation := fmt.Sprintf("Error: Unknown action '%s'. Only 'ReadFile' is supported.", action)
-			fmt.Printf("Observation: %s\n\n", observation)
-
-			messages = append(messages, types.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("Observation: %s", observation),
-			})
-			continue
-		}
-
-		content, err := tools.ReadFile(actionInput)
 		if err != nil {
-			observation := fmt.Sprintf("Error reading file: %v", err)
-			fmt.Printf("Observation: %s\n\n", observation)
-
-			messages = append(messages, types.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("Observation: %s", observation),
-			})
-			continue
+			log.Fatalf("Failed to create LLM client for summaries: %v", err)
 		}
-
-		observation := fmt.Sprintf("Observation: %s", content)
-		fmt.Println(observation)
-		fmt.Println()
-
-		messages = append(messages, types.Message{
-			Role:    "user",
-			Content: observation,
-		})
+		defer closeSummarizer()
+		summarizer = model
 	}
 
-	return fmt.Errorf("max iterations (%d) reached without final answer", maxIterations)
-}
+	config := react.DefaultConfig()
+	config.Verbose = true
+	config.MaxObservation = historyFlags.MaxObservation
+	config.History = historyFlags.History(summarizer)
+	config.ToolMode = *toolMode
 
-func parseAction(response string) (action string, actionInput string, found bool) {
-	actionRegex := regexp.MustCompile(`(?i)Action:\s*(\w+)`)
-	actionInputRegex := regexp.MustCompile(`(?i)Action Input:\s*(.+?)(?:\n|$)`)
+	registry := tools.NewRegistry(tools.ReadFileTool(fsys))
 
-	actionMatch := actionRegex.FindStringSubmatch(response)
-	if len(actionMatch) < 2 {
-		return "", "", false
+	prompt := systemPrompt
+	if *toolMode == react.NativeMode {
+		prompt = nativeSystemPrompt
 	}
-	action = strings.TrimSpace(actionMatch[1])
 
-	actionInputMatch := actionInputRegex.FindStringSubmatch(response)
-	if len(actionInputMatch) < 2 {
-		return "", "", false
+	agent := &react.Agent{
+		Name:         "ReAct Agent",
+		SystemPrompt: registry.Prompt(prompt),
+		Tools:        registry,
+		Client:       client,
+		Config:       config,
 	}
-	actionInput = strings.TrimSpace(actionInputMatch[1])
 
-	return action, actionInput, true
+	result, err := agent.Run(ctx, question)
+	fmt.Println()
+	if result != nil && result.Partial {
+		fmt.Printf("Partial answer: %s\n\n", result.Answer)
+	}
+	ledger.PrintSummary(os.Stdout)
+	if err != nil {
+		log.Fatalf("Error during ReAct loop: %v", err)
+	}
 }
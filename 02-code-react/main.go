@@ -2,15 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"regexp"
-	"strings"
 
-	"github.com/toumakido/reAct/lib/bedrock"
+	"github.com/toumakido/reAct/lib/backend"
+	"github.com/toumakido/reAct/lib/cassette"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/react"
 	"github.com/toumakido/reAct/lib/tools"
-	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
 )
 
 const systemPrompt = `You are a code analysis assistant that can read Go source files to answer questions about function implementations.
@@ -28,7 +30,7 @@ The system will then provide an Observation with the result.
 Example of YOUR output:
 Thought: I need to see what files are available first.
 Action: ListFiles
-Action Input: ListFiles
+Action Input: none
 
 After receiving the Observation from the system, continue:
 Thought: Now I should read the math.go file to find the Add function.
@@ -39,124 +41,114 @@ When you have gathered all necessary information, provide the final answer:
 
 Final Answer: [Your complete answer to the user's question]
 
-Available Actions:
-- ListFiles: Lists all Go files in the data directory. No input required, just write "ListFiles" without any Action Input.
-- ReadFile: Reads a Go source file from the data directory. Input should be just the filename (e.g., "math.go")
+{{tools}}
 
 Important:
+- To see how a function is implemented, prefer GetSymbol over reading the whole file
 - YOU output: Thought, Action, Action Input
 - SYSTEM provides: Observation
 - Continue until you can provide the Final Answer`
 
-const maxIterations = 15
+const nativeSystemPrompt = `You are a code analysis assistant that can read Go source files to answer questions about function implementations.
+
+- Use ListFiles to see the available files and ReadFile to read one.
+- To see how a function is implemented, prefer GetSymbol over reading the whole file; ListSymbols shows the declared names.
+- If the Exec tool is available, use it to check your conclusions by building, vetting or testing the code.
+- When you have all necessary information, reply without calling any tool. Start that reply with "Final Answer:" followed by your complete answer.`
+
+// stopSequence ends generation before the model writes its own Observation
+const stopSequence = "\nObservation:"
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run . \"Your question here\"")
+	backendFlags := backend.RegisterFlags(flag.CommandLine, "")
+	usageFlags := usage.RegisterFlags(flag.CommandLine)
+	historyFlags := react.RegisterFlags(flag.CommandLine)
+	toolMode := react.RegisterToolModeFlag(flag.CommandLine)
+	dataDir := flag.String("data", tools.DataDir("data", "02-code-react/data"), "Directory the file tools can read")
+	allowExec := flag.Bool("exec", false, "Let the agent run go build, vet and test in the data directory; tests run its code with your full filesystem access")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatal("Usage: go run . [flags] \"Your question here\"")
 	}
 
-	question := os.Args[1]
+	question := flag.Arg(0)
 
-	ctx := context.Background()
-
-	client, err := bedrock.NewClient(ctx)
+	ledger, err := usageFlags.NewLedger()
 	if err != nil {
-		log.Fatalf("Failed to create Bedrock client: %v", err)
+		log.Fatalf("Failed to load price table: %v", err)
 	}
-
-	if err := runReActLoop(ctx, client, question); err != nil {
-		log.Fatalf("Error during ReAct loop: %v", err)
+	ctx := usage.WithLedger(context.Background(), ledger)
+	budget, err := usageFlags.Budget()
+	if err != nil {
+		log.Fatalf("Invalid budget: %v", err)
 	}
-}
+	ctx, cancel := usage.WithBudget(ctx, budget)
+	defer cancel()
 
-func runReActLoop(ctx context.Context, client *bedrock.Client, question string) error {
-	messages := []types.Message{
-		{
-			Role:    "user",
-			Content: question,
-		},
+	client, closeClient, err := cassette.FromEnv(func() (llm.LLM, error) {
+		return backendFlags.New(ctx, backend.Settings{StopSequences: []string{stopSequence}})
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM client: %v", err)
 	}
+	defer closeClient()
 
-	fmt.Println("=== Starting Code Analysis ReAct Agent ===")
-	fmt.Printf("Question: %s\n\n", question)
-
-	for i := 0; i < maxIterations; i++ {
-		fmt.Printf("--- Iteration %d ---\n", i+1)
-
-		result, err := client.InvokeModel(ctx, systemPrompt, messages)
-		if err != nil {
-			return fmt.Errorf("failed to invoke model: %w", err)
-		}
-
-		fmt.Println(result.Text)
-		fmt.Printf("\n[Token Usage] Input: %d, Output: %d, Total: %d\n\n",
-			result.InputTokens, result.OutputTokens, result.InputTokens+result.OutputTokens)
+	fsys, err := tools.OpenFS(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fsys.Close()
 
-		messages = append(messages, types.Message{
-			Role:    "assistant",
-			Content: result.Text,
+	// Summaries can use a cheaper model on the same backend
+	summarizer := client
+	if historyFlags.Summarize && historyFlags.SummarizeModel != "" {
+		model, closeSummarizer, err := cassette.FromEnv(func() (llm.LLM, error) {
+			return backendFlags.WithModel(historyFlags.SummarizeModel).New(ctx, backend.Settings{})
 		})
-
-		if strings.Contains(result.Text, "Final Answer:") {
-			fmt.Println("=== Agent Complete ===")
-			return nil
-		}
-
-		action, actionInput, found := parseAction(result.Text)
-		if !found {
-			continue
-		}
-
-		var observation string
-		switch action {
-		case "ListFiles":
-			result, err := tools.ListFiles()
-			if err != nil {
-				observation = fmt.Sprintf("Error listing files: %v", err)
-			} else {
-				observation = result
-			}
-
-		case "ReadFile":
-			if actionInput == "" {
-				observation = "Error: ReadFile requires a filename as Action Input"
-			} else {
-				content, err := tools.ReadFile(actionInput)
-				if err != nil {
-					observation = fmt.Sprintf("Error reading file: %v", err)
-				} else {
-					observation = fmt.Sprintf("Content of %s:\n%s", actionInput, content)
-				}
-			}
-
-		default:
-			observation = fmt.Sprintf("Error: Unknown action '%s'. Available actions: ListFiles, ReadFile", action)
+		if err != nil {
+			log.Fatalf("Failed to create LLM client for summaries: %v", err)
 		}
-
-		fmt.Printf("Observation: %s\n\n", observation)
-
-		messages = append(messages, types.Message{
-			Role:    "user",
-			Content: fmt.Sprintf("Observation: %s", observation),
-		})
+		defer closeSummarizer()
+		summarizer = model
 	}
 
-	return fmt.Errorf("max iterations (%d) reached without final answer", maxIterations)
-}
+	config := react.DefaultConfig()
+	config.Verbose = true
+	config.MaxObservation = historyFlags.MaxObservation
+	config.History = historyFlags.History(summarizer)
+	config.ToolMode = *toolMode
+
+	registry := tools.NewRegistry(
+		tools.ListFilesTool(fsys),
+		tools.ReadFileTool(fsys),
+		tools.ListSymbolsTool(fsys),
+		tools.GetSymbolTool(fsys),
+	)
+	if *allowExec {
+		registry.Register(tools.ExecTool(fsys, tools.DefaultExecConfig()))
+	}
 
-func parseAction(response string) (action string, actionInput string, found bool) {
-	actionRegex := regexp.MustCompile(`(?i)Action:\s*(\w+)`)
-	actionMatch := actionRegex.FindStringSubmatch(response)
-	if len(actionMatch) < 2 {
-		return "", "", false
+	prompt := systemPrompt
+	if *toolMode == react.NativeMode {
+		prompt = nativeSystemPrompt
 	}
-	action = strings.TrimSpace(actionMatch[1])
 
-	actionInputRegex := regexp.MustCompile(`(?i)Action Input:\s*(.+?)(?:\n|$)`)
-	actionInputMatch := actionInputRegex.FindStringSubmatch(response)
-	if len(actionInputMatch) >= 2 {
-		actionInput = strings.TrimSpace(actionInputMatch[1])
+	agent := &react.Agent{
+		Name:         "Code Analysis ReAct Agent",
+		SystemPrompt: registry.Prompt(prompt),
+		Tools:        registry,
+		Client:       client,
+		Config:       config,
 	}
 
-	return action, actionInput, true
+	result, err := agent.Run(ctx, question)
+	fmt.Println()
+	if result != nil && result.Partial {
+		fmt.Printf("Partial answer: %s\n\n", result.Answer)
+	}
+	ledger.PrintSummary(os.Stdout)
+	if err != nil {
+		log.Fatalf("Error during ReAct loop: %v", err)
+	}
 }
@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"regexp"
 	"strings"
 
-	"github.com/toumakido/reAct/lib/bedrock"
-	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/backend"
+	"github.com/toumakido/reAct/lib/cassette"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/ratelimit"
+	"github.com/toumakido/reAct/lib/react"
+	"github.com/toumakido/reAct/lib/tools"
+	"github.com/toumakido/reAct/lib/usage"
 	"github.com/toumakido/reAct/subagents/codeanalysis"
 )
 
@@ -19,25 +25,22 @@ const systemPrompt = `You are a code analysis orchestrator that delegates tasks
 
 You MUST delegate code analysis tasks to the appropriate subagent. NEVER make assumptions or invent information about the codebase. All analysis should be performed by subagents that have access to the actual files.
 
-## Available Tools
-
-### CallSubagent
-**Function**: Delegates code analysis tasks to a specialized ReAct subagent
-**Usage**:
-  Action: CallSubagent
-  Action Input: codeanalysis|[your question in English]
-**Input Format**: "subagent_name|question"
+{{tools}}
 
 **IMPORTANT**: All questions to subagents MUST be in English.
 
-**Available Subagents**:
+## Available Subagents
 
-#### codeanalysis
+### codeanalysis
 Performs comprehensive code analysis using autonomous ReAct loop with file exploration tools.
 
 **Capabilities:**
 - Explores directory structure (ListFiles tool)
-- Reads Go source files (ReadFile tool)
+- Reads Go source files (ReadFile, ReadFileRange tools)
+- Searches code and looks up declarations (SearchFiles, ListSymbols, GetSymbol tools)
+- Finds references, implementations and callers across files (QuerySymbol tool)
+- Extracts the HTTP route table with handlers and middleware (ListRoutes tool)
+- Builds, vets and tests the code when enabled (Exec tool)
 - Analyzes code structure, relationships, and patterns
 - Synthesizes information across multiple files
 - Responds in any language (not limited to Japanese)
@@ -101,127 +104,164 @@ Final Answer: [Your complete and detailed answer to the user's question IN JAPAN
 
 **CRITICAL**: Final Answer MUST always be in Japanese, regardless of the language of user's question or subagent's response.`
 
-const maxIterations = 15
+const nativeSystemPrompt = `You are a code analysis orchestrator that delegates tasks to specialized subagents.
+
+You MUST delegate code analysis tasks to the codeanalysis subagent with the CallSubagent tool. NEVER make assumptions or invent information about the codebase.
+
+- Call CallSubagent with "codeanalysis|<question>". The codeanalysis subagent explores the files, symbols, routes and references of the Go API server and answers in any language.
+- The question to the subagent MUST be in English. If the user asked in Japanese, translate it to English first.
+- When you have the subagent's answer, reply without calling any tool. Start that reply with "Final Answer:" followed by your complete answer IN JAPANESE, translating the subagent's answer if needed.`
+
+// stopSequence ends generation before the model writes its own Observation
+const stopSequence = "\nObservation:"
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run . \"Your question here\"")
+	backendFlags := backend.RegisterFlags(flag.CommandLine, "")
+	subagentFlags := backend.RegisterFlags(flag.CommandLine, "subagent-")
+	limitConfig := ratelimit.RegisterFlags(flag.CommandLine)
+	usageFlags := usage.RegisterFlags(flag.CommandLine)
+	historyFlags := react.RegisterFlags(flag.CommandLine)
+	toolMode := react.RegisterToolModeFlag(flag.CommandLine)
+	dataDir := flag.String("data", tools.DataDir("data", "03-api-server-react/data"), "Directory the file tools can read")
+	allowExec := flag.Bool("exec", false, "Let the subagent run go build, vet and test in the data directory; tests run its code with your full filesystem access")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatal("Usage: go run . [flags] \"Your question here\"")
 	}
 
-	question := os.Args[1]
-
-	ctx := context.Background()
+	question := flag.Arg(0)
 
-	client, err := bedrock.NewClient(ctx)
+	ledger, err := usageFlags.NewLedger()
 	if err != nil {
-		log.Fatalf("Failed to create Bedrock client: %v", err)
+		log.Fatalf("Failed to load price table: %v", err)
 	}
-
-	if err := runReActLoop(ctx, client, question); err != nil {
-		log.Fatalf("Error during ReAct loop: %v", err)
+	ctx := usage.WithLedger(context.Background(), ledger)
+	budget, err := usageFlags.Budget()
+	if err != nil {
+		log.Fatalf("Invalid budget: %v", err)
 	}
-}
+	ctx, cancel := usage.WithBudget(ctx, budget)
+	defer cancel()
 
-func runReActLoop(ctx context.Context, client *bedrock.Client, question string) error {
-	messages := []types.Message{
-		{
-			Role:    "user",
-			Content: question,
-		},
+	// The orchestrator and subagent share one limiter so together they stay under the account quota
+	var limiter *ratelimit.Limiter
+	if limitConfig.Enabled() {
+		limiter = ratelimit.New(*limitConfig)
 	}
 
-	fmt.Println("=== Starting API Server Analysis ReAct Agent ===")
-	fmt.Printf("Question: %s\n\n", question)
-
-	for i := 0; i < maxIterations; i++ {
-		fmt.Printf("--- Iteration %d ---\n", i+1)
+	client, closeClient, err := cassette.FromEnv(func() (llm.LLM, error) {
+		return backendFlags.New(ctx, backend.Settings{StopSequences: []string{stopSequence}, Limiter: limiter})
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM client: %v", err)
+	}
+	defer closeClient()
 
-		result, err := client.InvokeModel(ctx, systemPrompt, messages)
-		if err != nil {
-			return fmt.Errorf("failed to invoke model: %w", err)
-		}
+	fsys, err := tools.OpenFS(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fsys.Close()
 
-		fmt.Println(result.Text)
-		fmt.Printf("\n[Token Usage] Input: %d, Output: %d, Total: %d\n\n",
-			result.InputTokens, result.OutputTokens, result.InputTokens+result.OutputTokens)
+	subagentClient, closeSubagentClient, err := cassette.FromEnv(func() (llm.LLM, error) {
+		return subagentFlags.New(ctx, backend.Settings{StopSequences: []string{stopSequence}, Limiter: limiter})
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM client for subagent: %v", err)
+	}
+	defer closeSubagentClient()
 
-		messages = append(messages, types.Message{
-			Role:    "assistant",
-			Content: result.Text,
+	// Summaries can use a cheaper model on the same backend
+	summarizer, subagentSummarizer := client, subagentClient
+	if historyFlags.Summarize && historyFlags.SummarizeModel != "" {
+		model, closeSummarizer, err := cassette.FromEnv(func() (llm.LLM, error) {
+			return backendFlags.WithModel(historyFlags.SummarizeModel).New(ctx, backend.Settings{Limiter: limiter})
 		})
-
-		if strings.Contains(result.Text, "Final Answer:") {
-			fmt.Println("=== Agent Complete ===")
-			return nil
-		}
-
-		action, actionInput, found := parseAction(result.Text)
-		if !found {
-			continue
+		if err != nil {
+			log.Fatalf("Failed to create LLM client for summaries: %v", err)
 		}
+		defer closeSummarizer()
+		summarizer, subagentSummarizer = model, model
+	}
 
-		var observation string
-		switch action {
-		case "CallSubagent":
-			if actionInput == "" {
-				observation = "Error: CallSubagent requires 'subagent_name|question' as Action Input"
-			} else {
-				parts := strings.SplitN(actionInput, "|", 2)
-				if len(parts) != 2 {
-					observation = "Error: CallSubagent input format should be 'subagent_name|question'"
-				} else {
-					subagentName := strings.TrimSpace(parts[0])
-					subagentQuestion := strings.TrimSpace(parts[1])
-
-					switch subagentName {
-					case "codeanalysis":
-						fmt.Printf("\n>>> Delegating to codeanalysis subagent...\n")
-						fmt.Printf(">>> Question: %s\n\n", subagentQuestion)
-
-						config := codeanalysis.DefaultConfig()
-
-						answer, err := codeanalysis.RunAnalysis(ctx, client, subagentQuestion, config)
-						if err != nil {
-							observation = fmt.Sprintf("Error calling codeanalysis subagent: %v", err)
-						} else {
-							observation = answer
-							fmt.Printf("\n>>> Subagent completed\n\n")
-						}
-
-					default:
-						observation = fmt.Sprintf("Error: Unknown subagent '%s'. Available subagents: codeanalysis", subagentName)
-					}
-				}
-			}
-
-		default:
-			observation = fmt.Sprintf("Error: Unknown action '%s'. Available actions: CallSubagent", action)
-		}
+	config := react.DefaultConfig()
+	config.Verbose = true
+	config.MaxObservation = historyFlags.MaxObservation
+	config.History = historyFlags.History(summarizer)
+	config.ToolMode = *toolMode
+
+	// File contents fill the subagent's context, so it gets the same limits and summarizes with its own model
+	// unless -summarize-model is set
+	subagentConfig := codeanalysis.DefaultConfig()
+	subagentConfig.MaxObservation = historyFlags.MaxObservation
+	subagentConfig.History = historyFlags.History(subagentSummarizer)
+	subagentConfig.ToolMode = *toolMode
+	if *allowExec {
+		execConfig := tools.DefaultExecConfig()
+		subagentConfig.Exec = &execConfig
+	}
 
-		fmt.Printf("Observation: %s\n\n", observation)
+	registry := tools.NewRegistry(callSubagentTool(subagentClient, fsys, subagentConfig))
 
-		messages = append(messages, types.Message{
-			Role:    "user",
-			Content: fmt.Sprintf("Observation: %s", observation),
-		})
+	prompt := systemPrompt
+	if *toolMode == react.NativeMode {
+		prompt = nativeSystemPrompt
 	}
 
-	return fmt.Errorf("max iterations (%d) reached without final answer", maxIterations)
-}
+	agent := &react.Agent{
+		Name:         "API Server Analysis ReAct Agent",
+		SystemPrompt: registry.Prompt(prompt),
+		Tools:        registry,
+		Client:       client,
+		Config:       config,
+	}
 
-func parseAction(response string) (action string, actionInput string, found bool) {
-	actionRegex := regexp.MustCompile(`(?i)Action:\s*(\w+)`)
-	actionMatch := actionRegex.FindStringSubmatch(response)
-	if len(actionMatch) < 2 {
-		return "", "", false
+	result, err := agent.Run(ctx, question)
+	fmt.Println()
+	if result != nil && result.Partial {
+		fmt.Printf("Partial answer: %s\n\n", result.Answer)
+	}
+	ledger.PrintSummary(os.Stdout)
+	if err != nil {
+		log.Fatalf("Error during ReAct loop: %v", err)
 	}
-	action = strings.TrimSpace(actionMatch[1])
 
-	actionInputRegex := regexp.MustCompile(`(?i)Action Input:\s*(.+?)(?:\n|$)`)
-	actionInputMatch := actionInputRegex.FindStringSubmatch(response)
-	if len(actionInputMatch) >= 2 {
-		actionInput = strings.TrimSpace(actionInputMatch[1])
+	if limiter != nil {
+		fmt.Printf("\n[Rate Limit] %s\n", limiter.Stats())
 	}
+}
+
+// callSubagentTool returns the CallSubagent tool, which runs the named subagent with the given question
+func callSubagentTool(client llm.LLM, fsys *tools.FS, config codeanalysis.Config) tools.Tool {
+	return tools.New("CallSubagent",
+		"Delegates code analysis tasks to a specialized ReAct subagent",
+		llm.StringInputSchema("request", "subagent_name|question, e.g. codeanalysis|What endpoints does this API server provide?"),
+		func(ctx context.Context, actionInput string) (string, error) {
+			subagentName, subagentQuestion, ok := strings.Cut(actionInput, "|")
+			if !ok {
+				return "", errors.New("input format should be 'subagent_name|question'")
+			}
+			subagentName = strings.TrimSpace(subagentName)
+			subagentQuestion = strings.TrimSpace(subagentQuestion)
+
+			switch subagentName {
+			case "codeanalysis":
+				fmt.Printf("\n>>> Delegating to codeanalysis subagent...\n")
+				fmt.Printf(">>> Question: %s\n\n", subagentQuestion)
+
+				answer, err := codeanalysis.RunAnalysis(ctx, client, fsys, subagentQuestion, config)
+				if err != nil {
+					if answer != "" {
+						return "", fmt.Errorf("codeanalysis subagent failed: %w\nPartial answer: %s", err, answer)
+					}
+					return "", fmt.Errorf("codeanalysis subagent failed: %w", err)
+				}
+				fmt.Printf("\n>>> Subagent completed\n\n")
+				return answer, nil
 
-	return action, actionInput, true
+			default:
+				return "", fmt.Errorf("unknown subagent '%s'. Available subagents: codeanalysis", subagentName)
+			}
+		})
 }
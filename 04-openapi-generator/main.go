@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/toumakido/reAct/lib/backend"
+	"github.com/toumakido/reAct/lib/cassette"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/openapi"
+	"github.com/toumakido/reAct/lib/tools"
+	"github.com/toumakido/reAct/lib/usage"
+)
+
+func main() {
+	backendFlags := backend.RegisterFlags(flag.CommandLine, "")
+	usageFlags := usage.RegisterFlags(flag.CommandLine)
+	dataDir := flag.String("data", tools.DataDir("03-api-server-react/data", "../03-api-server-react/data"), "Go module of the API server to document")
+	output := flag.String("o", "", "Write the document to this file instead of stdout")
+	title := flag.String("title", "API", "Title of the API")
+	version := flag.String("version", "1.0.0", "Version of the API")
+	describe := flag.Bool("describe", true, "Write operation summaries and descriptions with the LLM")
+	flag.Parse()
+
+	ledger, err := usageFlags.NewLedger()
+	if err != nil {
+		log.Fatalf("Failed to load price table: %v", err)
+	}
+	ctx := usage.WithLedger(context.Background(), ledger)
+	budget, err := usageFlags.Budget()
+	if err != nil {
+		log.Fatalf("Invalid budget: %v", err)
+	}
+	ctx, cancel := usage.WithBudget(ctx, budget)
+	defer cancel()
+
+	fsys, err := tools.OpenFS(*dataDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer fsys.Close()
+
+	doc, warnings, err := openapi.Generate(ctx, fsys, openapi.Info{Title: *title, Version: *version})
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err)
+	}
+
+	if *describe {
+		client, closeClient, err := cassette.FromEnv(func() (llm.LLM, error) {
+			return backendFlags.New(ctx, backend.Settings{})
+		})
+		if err != nil {
+			log.Fatalf("Failed to create LLM client: %v", err)
+		}
+		defer closeClient()
+
+		describeWarnings, err := openapi.Describe(ctx, client, doc)
+		warnings = append(warnings, describeWarnings...)
+		if err != nil {
+			// The document is still written, without the remaining descriptions
+			warnings = append(warnings, fmt.Sprintf("stopped describing operations: %v", err))
+		}
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "[Warning] %s\n", warning)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		log.Fatalf("Failed to write OpenAPI document: %v", err)
+	}
+
+	if *describe {
+		fmt.Fprintln(os.Stderr)
+		ledger.PrintSummary(os.Stderr)
+	}
+}
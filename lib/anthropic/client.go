@@ -0,0 +1,210 @@
+// Package anthropic is an llm.LLM backend for the Anthropic Messages API
+package anthropic
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/ratelimit"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+const (
+	// DefaultBaseURL is the public Anthropic API endpoint
+	DefaultBaseURL = "https://api.anthropic.com"
+	// DefaultModel is the model used when WithModel is not given
+	DefaultModel = "claude-haiku-4-5"
+
+	apiVersion       = "2023-06-01"
+	defaultMaxTokens = 4096
+)
+
+type Client struct {
+	httpClient    *http.Client
+	baseURL       string
+	apiKey        string
+	model         string
+	maxTokens     int
+	temperature   *float64
+	topP          *float64
+	stopSequences []string
+	promptCache   bool
+	limiter       *ratelimit.Limiter
+}
+
+type messagesRequest struct {
+	Model         string               `json:"model"`
+	MaxTokens     int                  `json:"max_tokens"`
+	System        []types.ContentBlock `json:"system,omitempty"`
+	Messages      []types.Message      `json:"messages"`
+	Tools         []llm.ToolSpec       `json:"tools,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+}
+
+type messagesResponse struct {
+	Model      string               `json:"model"`
+	Content    []types.ContentBlock `json:"content"`
+	StopReason string               `json:"stop_reason"`
+	Usage      types.Usage          `json:"usage"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+var (
+	_ llm.LLM        = (*Client)(nil)
+	_ llm.ToolCaller = (*Client)(nil)
+	_ llm.Streamer   = (*Client)(nil)
+)
+
+// NewClient creates a Messages API client authenticated with apiKey
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    DefaultBaseURL,
+		apiKey:     apiKey,
+		model:      DefaultModel,
+		maxTokens:  defaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.baseURL = strings.TrimRight(c.baseURL, "/")
+	return c
+}
+
+// Complete implements llm.LLM
+func (c *Client) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	return c.complete(ctx, c.newRequest(systemPrompt, messages))
+}
+
+// CompleteWithTools implements llm.ToolCaller
+func (c *Client) CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*llm.Result, error) {
+	request := c.newRequest(systemPrompt, messages)
+	request.Tools = tools
+	return c.complete(ctx, request)
+}
+
+func (c *Client) newRequest(systemPrompt string, messages []types.Message) messagesRequest {
+	var system []types.ContentBlock
+	if systemPrompt != "" {
+		system = []types.ContentBlock{{Type: types.BlockText, Text: systemPrompt}}
+	}
+	if c.promptCache {
+		system, messages = types.WithCacheBreakpoints(system, messages)
+	}
+
+	return messagesRequest{
+		Model:         c.model,
+		MaxTokens:     c.maxTokens,
+		System:        system,
+		Messages:      messages,
+		Temperature:   c.temperature,
+		TopP:          c.topP,
+		StopSequences: c.stopSequences,
+	}
+}
+
+func (c *Client) complete(ctx context.Context, request messagesRequest) (*llm.Result, error) {
+	body, reservation, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var response messagesResponse
+	err = json.NewDecoder(body).Decode(&response)
+	reservation.Done(response.Usage.InputTokens + response.Usage.OutputTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(response.Content) == 0 {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	result := &llm.Result{
+		StopReason: response.StopReason,
+		Model:      cmp.Or(response.Model, c.model),
+	}
+	result.SetUsage(response.Usage)
+	result.SetContent(response.Content)
+
+	return result, nil
+}
+
+// post sends a Messages API request and returns the response body on HTTP 200.
+// The caller must complete the reservation with the tokens actually used.
+func (c *Client) post(ctx context.Context, request messagesRequest) (io.ReadCloser, *ratelimit.Reservation, error) {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Anthropic-Version", apiVersion)
+
+	reservation, err := c.limiter.Acquire(ctx, len(requestBody)/4+c.maxTokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		reservation.Done(0)
+		return nil, nil, fmt.Errorf("failed to invoke model: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		reservation.Done(0)
+		return nil, nil, fmt.Errorf("failed to invoke model: %w", readError(resp))
+	}
+
+	return resp.Body, reservation, nil
+}
+
+// APIError is an error response from the Messages API
+type APIError struct {
+	StatusCode int
+	// Type is the error type, e.g. "rate_limit_error" or "overloaded_error"
+	Type    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("anthropic: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+func readError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var body errorResponse
+	if err := json.Unmarshal(raw, &body); err == nil && body.Error.Type != "" {
+		apiErr.Type = body.Error.Type
+		apiErr.Message = body.Error.Message
+	} else {
+		apiErr.Type = http.StatusText(resp.StatusCode)
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	return apiErr
+}
@@ -0,0 +1,178 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// newTestClient starts a server running handler and returns a client pointed at it
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient("test-key", append([]Option{WithBaseURL(server.URL + "/")}, opts...)...)
+}
+
+// requestBody is the part of a Messages API request the tests inspect
+type requestBody struct {
+	Model     string `json:"model"`
+	MaxTokens int    `json:"max_tokens"`
+	System    []struct {
+		Text         string              `json:"text"`
+		CacheControl *types.CacheControl `json:"cache_control"`
+	} `json:"system"`
+	Messages      []types.Message `json:"messages"`
+	Tools         []llm.ToolSpec  `json:"tools"`
+	StopSequences []string        `json:"stop_sequences"`
+	Stream        bool            `json:"stream"`
+}
+
+func TestCompleteRequest(t *testing.T) {
+	var got requestBody
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if key := r.Header.Get("X-Api-Key"); key != "test-key" {
+			t.Errorf("X-Api-Key = %q, want test-key", key)
+		}
+		if version := r.Header.Get("Anthropic-Version"); version != apiVersion {
+			t.Errorf("Anthropic-Version = %q, want %s", version, apiVersion)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`)
+	},
+		WithModel("claude-test"),
+		WithMaxTokens(100),
+		WithStopSequences("\nObservation:"),
+		WithPromptCaching(true),
+	)
+
+	tools := []llm.ToolSpec{{Name: "ReadFile", Description: "Reads a file", InputSchema: llm.StringInputSchema("filename", "File to read")}}
+	messages := []types.Message{
+		{Role: "user", Content: "question"},
+		{Role: "assistant", Content: "thought"},
+		{Role: "user", Content: "Observation: result"},
+	}
+	if _, err := client.CompleteWithTools(context.Background(), "system prompt", messages, tools); err != nil {
+		t.Fatalf("CompleteWithTools: %v", err)
+	}
+
+	if got.Model != "claude-test" || got.MaxTokens != 100 {
+		t.Errorf("model, max_tokens = %s, %d, want claude-test, 100", got.Model, got.MaxTokens)
+	}
+	if len(got.System) != 1 || got.System[0].Text != "system prompt" || got.System[0].CacheControl == nil {
+		t.Errorf("system = %+v, want one cached block with the system prompt", got.System)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got.Messages))
+	}
+	last := got.Messages[2].Blocks
+	if len(last) != 1 || last[0].Text != "Observation: result" || last[0].CacheControl == nil {
+		t.Errorf("last message = %+v, want a cached text block", last)
+	}
+	if first := got.Messages[0]; first.Content != "question" || len(first.Blocks) != 0 {
+		t.Errorf("first message = %+v, want plain text without a cache breakpoint", first)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "ReadFile" {
+		t.Errorf("tools = %+v, want ReadFile", got.Tools)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "\nObservation:" {
+		t.Errorf("stop_sequences = %q", got.StopSequences)
+	}
+	if got.Stream {
+		t.Errorf("stream is set on a non-streaming request")
+	}
+}
+
+func TestCompleteResponse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{
+			"model": "claude-test-20250101",
+			"content": [
+				{"type": "thinking", "thinking": "hmm", "signature": "sig"},
+				{"type": "text", "text": "Let me read it."},
+				{"type": "tool_use", "id": "toolu_1", "name": "ReadFile", "input": {"filename": "a.go"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 120, "output_tokens": 30, "cache_read_input_tokens": 100, "cache_creation_input_tokens": 20}
+		}`)
+	})
+
+	result, err := client.Complete(context.Background(), "", []types.Message{{Role: "user", Content: "q"}})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if result.Text != "Let me read it." {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if len(result.Content) != 3 || result.Content[0].Type != types.BlockThinking {
+		t.Errorf("Content = %+v, want all three blocks including thinking", result.Content)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].ID != "toolu_1" || result.ToolCalls[0].Name != "ReadFile" {
+		t.Fatalf("ToolCalls = %+v", result.ToolCalls)
+	}
+	if input := llm.InputText(result.ToolCalls[0].Input); input != "a.go" {
+		t.Errorf("tool input = %q, want a.go", input)
+	}
+	if result.StopReason != llm.StopToolUse || result.Model != "claude-test-20250101" {
+		t.Errorf("StopReason, Model = %s, %s", result.StopReason, result.Model)
+	}
+	if result.InputTokens != 120 || result.OutputTokens != 30 || result.CacheReadInputTokens != 100 || result.CacheCreationInputTokens != 20 {
+		t.Errorf("usage = %d/%d/%d/%d, want 120/30/100/20",
+			result.InputTokens, result.OutputTokens, result.CacheReadInputTokens, result.CacheCreationInputTokens)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantType    string
+		wantMessage string
+	}{
+		{
+			name:        "json error",
+			status:      http.StatusTooManyRequests,
+			body:        `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			wantType:    "rate_limit_error",
+			wantMessage: "slow down",
+		},
+		{
+			name:        "plain text from a proxy",
+			status:      http.StatusBadGateway,
+			body:        "upstream unavailable\n",
+			wantType:    "Bad Gateway",
+			wantMessage: "upstream unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			_, err := client.Complete(context.Background(), "", []types.Message{{Role: "user", Content: "q"}})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMessage {
+				t.Errorf("APIError = %+v, want %d %s: %s", apiErr, tt.status, tt.wantType, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package anthropic
+
+import (
+	"net/http"
+
+	"github.com/toumakido/reAct/lib/ratelimit"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL overrides DefaultBaseURL, e.g. for a proxy or a local fake server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithModel sets the model name, e.g. "claude-sonnet-4-5"
+func WithModel(model string) Option {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithMaxTokens sets the maximum number of tokens to generate per call
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *Client) {
+		c.maxTokens = maxTokens
+	}
+}
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithTopP sets the nucleus sampling threshold
+func WithTopP(topP float64) Option {
+	return func(c *Client) {
+		c.topP = &topP
+	}
+}
+
+// WithStopSequences sets sequences that stop generation, e.g. "\nObservation:"
+func WithStopSequences(sequences ...string) Option {
+	return func(c *Client) {
+		c.stopSequences = sequences
+	}
+}
+
+// WithPromptCaching marks the system prompt and the latest message as prompt cache breakpoints
+func WithPromptCaching(enabled bool) Option {
+	return func(c *Client) {
+		c.promptCache = enabled
+	}
+}
+
+// WithLimiter makes the client wait on a rate limiter before each call
+func WithLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
@@ -0,0 +1,79 @@
+package anthropic
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// CompleteStream implements llm.Streamer. Generation is stopped early when fn returns false.
+func (c *Client) CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*llm.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	request := c.newRequest(systemPrompt, messages)
+	request.Stream = true
+
+	body, reservation, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	result := &llm.Result{Model: c.model}
+	defer func() {
+		reservation.Done(result.InputTokens + result.OutputTokens)
+	}()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+scan:
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var e types.StreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &e); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+
+		switch e.Type {
+		case "message_start":
+			result.Model = cmp.Or(e.Message.Model, c.model)
+			result.SetUsage(e.Message.Usage)
+		case "content_block_delta":
+			if e.Delta.Type != "text_delta" {
+				continue
+			}
+			text.WriteString(e.Delta.Text)
+			if !fn(e.Delta.Text) {
+				result.StopStream(text.String())
+				break scan
+			}
+		case "message_delta":
+			result.StopReason = e.Delta.StopReason
+			result.OutputTokens = e.Usage.OutputTokens
+		case "message_stop":
+			break scan
+		case "error":
+			return nil, fmt.Errorf("failed to read response stream: %w", &APIError{Type: e.Error.Type, Message: e.Error.Message})
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	result.Text = text.String()
+	result.Content = []types.ContentBlock{{Type: types.BlockText, Text: result.Text}}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// sse writes events in the server-sent event format of the Messages API
+func sse(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		var e struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal([]byte(event), &e)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, event)
+	}
+}
+
+func textDelta(text string) string {
+	return fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%q}}`, text)
+}
+
+func TestCompleteStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var got requestBody
+		json.NewDecoder(r.Body).Decode(&got)
+		if !got.Stream {
+			t.Errorf("stream is not set on a streaming request")
+		}
+		sse(w,
+			`{"type":"message_start","message":{"model":"claude-test-20250101","usage":{"input_tokens":50,"output_tokens":1,"cache_read_input_tokens":40}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			textDelta("Thought: read "),
+			textDelta("the file"),
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`,
+			`{"type":"message_stop"}`,
+		)
+	})
+
+	var deltas []string
+	result, err := client.CompleteStream(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, func(delta string) bool {
+		deltas = append(deltas, delta)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Thought: read |the file" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if result.Text != "Thought: read the file" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if result.StopReason != llm.StopEndTurn || result.Model != "claude-test-20250101" {
+		t.Errorf("StopReason, Model = %s, %s", result.StopReason, result.Model)
+	}
+	if result.InputTokens != 50 || result.OutputTokens != 7 || result.CacheReadInputTokens != 40 {
+		t.Errorf("usage = %d/%d/%d, want 50/7/40", result.InputTokens, result.OutputTokens, result.CacheReadInputTokens)
+	}
+}
+
+func TestCompleteStreamStoppedEarly(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sse(w,
+			`{"type":"message_start","message":{"usage":{"input_tokens":50,"output_tokens":1}}}`,
+			textDelta(long),
+			textDelta("never delivered"),
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":300}}`,
+		)
+	})
+
+	result, err := client.CompleteStream(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, func(string) bool {
+		return false
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream: %v", err)
+	}
+
+	if result.Text != long {
+		t.Errorf("Text has %d bytes, want only the first delta", len(result.Text))
+	}
+	// message_delta is never read, so the output tokens are estimated from the text
+	if result.StopReason != llm.StopSequence || result.OutputTokens != llm.EstimateTokens(long) {
+		t.Errorf("StopReason, OutputTokens = %s, %d, want %s, %d",
+			result.StopReason, result.OutputTokens, llm.StopSequence, llm.EstimateTokens(long))
+	}
+	if result.InputTokens != 50 {
+		t.Errorf("InputTokens = %d, want 50 from message_start", result.InputTokens)
+	}
+}
+
+func TestCompleteStreamErrorEvent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sse(w,
+			`{"type":"message_start","message":{"usage":{"input_tokens":50,"output_tokens":1}}}`,
+			textDelta("partial"),
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+		)
+	})
+
+	_, err := client.CompleteStream(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, func(string) bool {
+		return true
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *APIError", err)
+	}
+	if apiErr.Type != "overloaded_error" || apiErr.Message != "Overloaded" {
+		t.Errorf("APIError = %+v, want overloaded_error: Overloaded", apiErr)
+	}
+}
@@ -0,0 +1,156 @@
+// Package backend selects and configures the llm.LLM implementation used by the examples
+package backend
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/anthropic"
+	"github.com/toumakido/reAct/lib/bedrock"
+	"github.com/toumakido/reAct/lib/envvar"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/openai"
+	"github.com/toumakido/reAct/lib/ratelimit"
+)
+
+// Backend names accepted by -backend
+const (
+	Bedrock   = "bedrock"
+	Anthropic = "anthropic"
+	OpenAI    = "openai"
+)
+
+// Flags holds backend selection and settings parsed from the command line
+type Flags struct {
+	Backend          string
+	Bedrock          *bedrock.Flags
+	AnthropicBaseURL string
+	AnthropicModel   string
+	OpenAIBaseURL    string
+	OpenAIModel      string
+	MaxTokens        int
+	Temperature      float64
+	TopP             float64
+	PromptCache      bool
+}
+
+// Settings are applied to whichever backend is selected
+type Settings struct {
+	StopSequences []string
+	Limiter       *ratelimit.Limiter
+}
+
+// RegisterFlags registers -<prefix>backend, the sampling flags, the bedrock flags, the Anthropic API flags
+// and the OpenAI-compatible server flags on fs. Defaults are read from environment variables named after the
+// flags, e.g. prefix "subagent-" reads SUBAGENT_LLM_BACKEND and SUBAGENT_OPENAI_BASE_URL.
+// The max-tokens, temperature, top-p and prompt-cache flags apply to every backend and read LLM_MAX_TOKENS etc.
+// API keys are read from ANTHROPIC_API_KEY and OPENAI_API_KEY only.
+func RegisterFlags(fs *flag.FlagSet, prefix string) *Flags {
+	env := strings.ToUpper(strings.ReplaceAll(prefix, "-", "_"))
+	f := &Flags{
+		Bedrock: bedrock.RegisterFlags(fs, prefix),
+	}
+
+	fs.StringVar(&f.Backend, prefix+"backend", envOr(env+"LLM_BACKEND", Bedrock), "model backend: bedrock, anthropic or openai (env "+env+"LLM_BACKEND)")
+	fs.IntVar(&f.MaxTokens, prefix+"max-tokens", envvar.Int(env+"LLM_MAX_TOKENS", 0), "max tokens per call (env "+env+"LLM_MAX_TOKENS)")
+	fs.Float64Var(&f.Temperature, prefix+"temperature", envvar.Float(env+"LLM_TEMPERATURE", -1), "sampling temperature, negative for model default (env "+env+"LLM_TEMPERATURE)")
+	fs.Float64Var(&f.TopP, prefix+"top-p", envvar.Float(env+"LLM_TOP_P", -1), "top_p, negative for model default (env "+env+"LLM_TOP_P)")
+	fs.BoolVar(&f.PromptCache, prefix+"prompt-cache", envvar.Bool(env+"LLM_PROMPT_CACHE", true), "cache the system prompt and history between iterations (env "+env+"LLM_PROMPT_CACHE)")
+	fs.StringVar(&f.AnthropicBaseURL, prefix+"anthropic-base-url", envOr(env+"ANTHROPIC_BASE_URL", anthropic.DefaultBaseURL), "Anthropic API base URL (env "+env+"ANTHROPIC_BASE_URL)")
+	fs.StringVar(&f.AnthropicModel, prefix+"anthropic-model", envOr(env+"ANTHROPIC_MODEL", anthropic.DefaultModel), "Anthropic model name (env "+env+"ANTHROPIC_MODEL)")
+	fs.StringVar(&f.OpenAIBaseURL, prefix+"openai-base-url", envOr(env+"OPENAI_BASE_URL", "http://localhost:8080/v1"), "base URL of an OpenAI-compatible server (env "+env+"OPENAI_BASE_URL)")
+	fs.StringVar(&f.OpenAIModel, prefix+"openai-model", os.Getenv(env+"OPENAI_MODEL"), "model name on the OpenAI-compatible server (env "+env+"OPENAI_MODEL)")
+
+	return f
+}
+
+// New creates the selected backend
+func (f *Flags) New(ctx context.Context, s Settings) (llm.LLM, error) {
+	switch f.Backend {
+	case Bedrock:
+		opts := append(f.Bedrock.Options(),
+			bedrock.WithStopSequences(s.StopSequences...),
+			bedrock.WithPromptCaching(f.PromptCache),
+			bedrock.WithLimiter(s.Limiter),
+		)
+		if f.MaxTokens > 0 {
+			opts = append(opts, bedrock.WithMaxTokens(f.MaxTokens))
+		}
+		if f.Temperature >= 0 {
+			opts = append(opts, bedrock.WithTemperature(f.Temperature))
+		}
+		if f.TopP >= 0 {
+			opts = append(opts, bedrock.WithTopP(f.TopP))
+		}
+		return bedrock.NewClient(ctx, opts...)
+
+	case Anthropic:
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY is not set")
+		}
+		opts := []anthropic.Option{
+			anthropic.WithBaseURL(f.AnthropicBaseURL),
+			anthropic.WithModel(f.AnthropicModel),
+			anthropic.WithStopSequences(s.StopSequences...),
+			anthropic.WithPromptCaching(f.PromptCache),
+			anthropic.WithLimiter(s.Limiter),
+		}
+		if f.MaxTokens > 0 {
+			opts = append(opts, anthropic.WithMaxTokens(f.MaxTokens))
+		}
+		if f.Temperature >= 0 {
+			opts = append(opts, anthropic.WithTemperature(f.Temperature))
+		}
+		if f.TopP >= 0 {
+			opts = append(opts, anthropic.WithTopP(f.TopP))
+		}
+		return anthropic.NewClient(apiKey, opts...), nil
+
+	case OpenAI:
+		opts := []openai.Option{
+			openai.WithAPIKey(os.Getenv("OPENAI_API_KEY")),
+			openai.WithStopSequences(s.StopSequences...),
+			openai.WithLimiter(s.Limiter),
+		}
+		if f.MaxTokens > 0 {
+			opts = append(opts, openai.WithMaxTokens(f.MaxTokens))
+		}
+		if f.Temperature >= 0 {
+			opts = append(opts, openai.WithTemperature(f.Temperature))
+		}
+		if f.TopP >= 0 {
+			opts = append(opts, openai.WithTopP(f.TopP))
+		}
+		return openai.NewClient(f.OpenAIBaseURL, f.OpenAIModel, opts...), nil
+
+	default:
+		return nil, fmt.Errorf("unknown backend %q", f.Backend)
+	}
+}
+
+// WithModel returns a copy of f that uses model on the selected backend
+func (f *Flags) WithModel(model string) *Flags {
+	c := *f
+	switch f.Backend {
+	case Bedrock:
+		b := *f.Bedrock
+		b.ModelID = model
+		c.Bedrock = &b
+	case Anthropic:
+		c.AnthropicModel = model
+	case OpenAI:
+		c.OpenAIModel = model
+	}
+	return &c
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -1,6 +1,7 @@
 package bedrock
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,71 +9,141 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/ratelimit"
 	"github.com/toumakido/reAct/lib/types"
 )
 
 type Client struct {
-	client  *bedrockruntime.Client
-	modelID string
+	client        *bedrockruntime.Client
+	modelID       string
+	region        string
+	endpoint      string
+	retry         RetryPolicy
+	limiter       *ratelimit.Limiter
+	promptCache   bool
+	maxTokens     int
+	temperature   *float64
+	topP          *float64
+	stopSequences []string
 }
 
 type invokeRequest struct {
-	AnthropicVersion string          `json:"anthropic_version"`
-	MaxTokens        int             `json:"max_tokens"`
-	System           string          `json:"system,omitempty"`
-	Messages         []types.Message `json:"messages"`
+	AnthropicVersion string               `json:"anthropic_version"`
+	MaxTokens        int                  `json:"max_tokens"`
+	System           []types.ContentBlock `json:"system,omitempty"`
+	Messages         []types.Message      `json:"messages"`
+	Tools            []llm.ToolSpec       `json:"tools,omitempty"`
+	Temperature      *float64             `json:"temperature,omitempty"`
+	TopP             *float64             `json:"top_p,omitempty"`
+	StopSequences    []string             `json:"stop_sequences,omitempty"`
 }
 
 type invokeResponse struct {
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	Usage struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-	} `json:"usage"`
+	Model      string               `json:"model"`
+	Content    []types.ContentBlock `json:"content"`
+	StopReason string               `json:"stop_reason"`
+	Usage      types.Usage          `json:"usage"`
 }
 
-type InvokeResult struct {
-	Text         string
-	InputTokens  int
-	OutputTokens int
-}
+// InvokeResult is the reply returned by InvokeModel
+type InvokeResult = llm.Result
+
+var (
+	_ llm.LLM        = (*Client)(nil)
+	_ llm.ToolCaller = (*Client)(nil)
+)
 
 // NewClient creates a new Bedrock client
-func NewClient(ctx context.Context) (*Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	c := &Client{
+		modelID:   DefaultModelID,
+		maxTokens: defaultMaxTokens,
+		retry:     DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var loadOpts []func(*config.LoadOptions) error
+	if c.region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(c.region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	client := bedrockruntime.NewFromConfig(cfg)
+	c.client = bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		// Retries are handled by c.retry so that every caller shares one policy
+		o.Retryer = aws.NopRetryer{}
+		if c.endpoint != "" {
+			o.BaseEndpoint = aws.String(c.endpoint)
+		}
+	})
 
-	return &Client{
-		client:  client,
-		modelID: "global.anthropic.claude-haiku-4-5-20251001-v1:0",
-	}, nil
+	return c, nil
 }
 
 // InvokeModel sends messages to Claude and returns the response
 func (c *Client) InvokeModel(ctx context.Context, systemPrompt string, messages []types.Message) (*InvokeResult, error) {
-	request := invokeRequest{
+	return c.invoke(ctx, c.newRequest(systemPrompt, messages))
+}
+
+// InvokeModelWithTools sends messages along with tool definitions and returns
+// the response, including any tool_use blocks as ToolCalls
+func (c *Client) InvokeModelWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*InvokeResult, error) {
+	request := c.newRequest(systemPrompt, messages)
+	request.Tools = tools
+	return c.invoke(ctx, request)
+}
+
+// Complete implements llm.LLM
+func (c *Client) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	return c.InvokeModel(ctx, systemPrompt, messages)
+}
+
+// CompleteWithTools implements llm.ToolCaller
+func (c *Client) CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*llm.Result, error) {
+	return c.InvokeModelWithTools(ctx, systemPrompt, messages, tools)
+}
+
+func (c *Client) newRequest(systemPrompt string, messages []types.Message) invokeRequest {
+	var system []types.ContentBlock
+	if systemPrompt != "" {
+		system = []types.ContentBlock{{Type: types.BlockText, Text: systemPrompt}}
+	}
+	if c.promptCache {
+		system, messages = types.WithCacheBreakpoints(system, messages)
+	}
+
+	return invokeRequest{
 		AnthropicVersion: "bedrock-2023-05-31",
-		MaxTokens:        4096,
-		System:           systemPrompt,
+		MaxTokens:        c.maxTokens,
+		System:           system,
 		Messages:         messages,
+		Temperature:      c.temperature,
+		TopP:             c.topP,
+		StopSequences:    c.stopSequences,
 	}
+}
 
+func (c *Client) invoke(ctx context.Context, request invokeRequest) (*InvokeResult, error) {
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	output, err := c.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-		ModelId:     aws.String(c.modelID),
-		ContentType: aws.String("application/json"),
-		Body:        requestBody,
+	var output *bedrockruntime.InvokeModelOutput
+	reservation, err := c.send(ctx, requestBody, func() error {
+		var err error
+		output, err = c.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			ModelId:     aws.String(c.modelID),
+			ContentType: aws.String("application/json"),
+			Body:        requestBody,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to invoke model: %w", err)
@@ -80,16 +151,44 @@ func (c *Client) InvokeModel(ctx context.Context, systemPrompt string, messages
 
 	var response invokeResponse
 	if err := json.Unmarshal(output.Body, &response); err != nil {
+		reservation.Done(0)
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if len(response.Content) == 0 {
+		reservation.Done(response.Usage.InputTokens + response.Usage.OutputTokens)
 		return nil, fmt.Errorf("no content in response")
 	}
 
-	return &InvokeResult{
-		Text:         response.Content[0].Text,
-		InputTokens:  response.Usage.InputTokens,
-		OutputTokens: response.Usage.OutputTokens,
-	}, nil
+	result := &InvokeResult{
+		StopReason: response.StopReason,
+		Model:      cmp.Or(response.Model, c.modelID),
+	}
+	result.SetUsage(response.Usage)
+	result.SetContent(response.Content)
+	reservation.Done(result.InputTokens + result.OutputTokens)
+
+	return result, nil
+}
+
+// send runs call under the retry policy, waiting for the rate limiter before each attempt.
+// On success the returned reservation must be completed with the tokens actually used.
+func (c *Client) send(ctx context.Context, requestBody []byte, call func() error) (*ratelimit.Reservation, error) {
+	// Roughly four bytes per token for the prompt, plus the full output allowance
+	estimate := len(requestBody)/4 + c.maxTokens
+
+	var reservation *ratelimit.Reservation
+	err := c.retry.do(ctx, func() error {
+		r, err := c.limiter.Acquire(ctx, estimate)
+		if err != nil {
+			return err
+		}
+		if err := call(); err != nil {
+			r.Done(0)
+			return err
+		}
+		reservation = r
+		return nil
+	})
+	return reservation, err
 }
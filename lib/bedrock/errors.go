@@ -0,0 +1,111 @@
+package bedrock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Error kinds returned by the client. Match them with errors.Is.
+var (
+	ErrThrottled     = errors.New("bedrock: request throttled")
+	ErrUnavailable   = errors.New("bedrock: service temporarily unavailable")
+	ErrValidation    = errors.New("bedrock: invalid request")
+	ErrContextLength = errors.New("bedrock: context length exceeded")
+	ErrAuth          = errors.New("bedrock: authentication or authorization failed")
+)
+
+// APIError is a classified Bedrock failure
+type APIError struct {
+	// Kind is one of the Err* sentinels, or nil when the failure is unclassified
+	Kind       error
+	Code       string
+	StatusCode int
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	if e.Kind == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// Is reports whether target is the kind of this error
+func (e *APIError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// Retryable reports whether the failure is transient
+func (e *APIError) Retryable() bool {
+	return e.Kind == ErrThrottled || e.Kind == ErrUnavailable
+}
+
+// classify wraps an SDK error in an APIError based on its error code and HTTP status
+func classify(err error) *APIError {
+	apiErr := &APIError{Err: err}
+
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		apiErr.StatusCode = respErr.HTTPStatusCode()
+	}
+
+	var smithyErr smithy.APIError
+	if errors.As(err, &smithyErr) {
+		apiErr.Code = smithyErr.ErrorCode()
+		apiErr.Kind = kindFromCode(apiErr.Code, smithyErr.ErrorMessage())
+	}
+	if apiErr.Kind == nil {
+		apiErr.Kind = kindFromStatus(apiErr.StatusCode)
+	}
+
+	return apiErr
+}
+
+func kindFromCode(code, message string) error {
+	switch code {
+	case "ThrottlingException", "TooManyRequestsException", "ServiceQuotaExceededException":
+		return ErrThrottled
+	case "InternalServerException", "ServiceUnavailableException", "ModelNotReadyException", "ModelTimeoutException":
+		return ErrUnavailable
+	case "AccessDeniedException", "UnrecognizedClientException", "ExpiredTokenException",
+		"InvalidSignatureException", "IncompleteSignature", "MissingAuthenticationToken":
+		return ErrAuth
+	case "ValidationException":
+		if isContextLengthMessage(message) {
+			return ErrContextLength
+		}
+		return ErrValidation
+	}
+	return nil
+}
+
+func kindFromStatus(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrThrottled
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case status == http.StatusBadRequest:
+		return ErrValidation
+	case status >= http.StatusInternalServerError:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+func isContextLengthMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, s := range []string{"too long", "context length", "context window", "maximum context", "too many tokens"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package bedrock
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+// Flags holds the Bedrock-specific Client settings parsed from the command line.
+// Sampling settings shared by every backend are registered by lib/backend.
+type Flags struct {
+	ModelID string
+	Region  string
+}
+
+// RegisterFlags registers -<prefix>model and -<prefix>region on fs. Defaults are read from
+// the matching environment variables, e.g. prefix "subagent-" reads SUBAGENT_BEDROCK_MODEL_ID.
+func RegisterFlags(fs *flag.FlagSet, prefix string) *Flags {
+	env := strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "BEDROCK_"
+	f := &Flags{}
+
+	fs.StringVar(&f.ModelID, prefix+"model", os.Getenv(env+"MODEL_ID"), "Bedrock model ID (env "+env+"MODEL_ID)")
+	fs.StringVar(&f.Region, prefix+"region", os.Getenv(env+"REGION"), "AWS region (env "+env+"REGION)")
+
+	return f
+}
+
+// Options converts the parsed flags to client options; unset flags are omitted
+func (f *Flags) Options() []Option {
+	var opts []Option
+	if f.ModelID != "" {
+		opts = append(opts, WithModelID(f.ModelID))
+	}
+	if f.Region != "" {
+		opts = append(opts, WithRegion(f.Region))
+	}
+	return opts
+}
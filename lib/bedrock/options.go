@@ -0,0 +1,77 @@
+package bedrock
+
+import "github.com/toumakido/reAct/lib/ratelimit"
+
+// DefaultModelID is the model used when WithModelID is not given
+const DefaultModelID = "global.anthropic.claude-haiku-4-5-20251001-v1:0"
+
+const defaultMaxTokens = 4096
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithModelID sets the Bedrock model ID or inference profile
+func WithModelID(modelID string) Option {
+	return func(c *Client) {
+		c.modelID = modelID
+	}
+}
+
+// WithRegion sets the AWS region, overriding the shared config and AWS_REGION
+func WithRegion(region string) Option {
+	return func(c *Client) {
+		c.region = region
+	}
+}
+
+// WithMaxTokens sets the maximum number of tokens to generate per call
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *Client) {
+		c.maxTokens = maxTokens
+	}
+}
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithTopP sets the nucleus sampling threshold
+func WithTopP(topP float64) Option {
+	return func(c *Client) {
+		c.topP = &topP
+	}
+}
+
+// WithStopSequences sets sequences that stop generation, e.g. "\nObservation:"
+func WithStopSequences(sequences ...string) Option {
+	return func(c *Client) {
+		c.stopSequences = sequences
+	}
+}
+
+// WithEndpoint overrides the Bedrock Runtime endpoint URL, e.g. a local fake server
+func WithEndpoint(url string) Option {
+	return func(c *Client) {
+		c.endpoint = url
+	}
+}
+
+// WithLimiter makes the client wait on a rate limiter before each call.
+// Share one limiter between clients to keep them under a common quota.
+func WithLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithPromptCaching marks the system prompt and the latest message as prompt cache
+// breakpoints, so the static prompt and the history up to the last observation are
+// read from cache on the next iteration instead of being billed in full
+func WithPromptCaching(enabled bool) Option {
+	return func(c *Client) {
+		c.promptCache = enabled
+	}
+}
@@ -0,0 +1,165 @@
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// fakeBedrock serves InvokeModel, answering request n (from 1) with respond(n)
+func fakeBedrock(t *testing.T, respond func(w http.ResponseWriter, n int)) (endpoint string, requests *atomic.Int32) {
+	t.Helper()
+	requests = &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		io.Copy(io.Discard, r.Body)
+		respond(w, n)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+// writeError writes a Bedrock Runtime error response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Amzn-Errortype", code)
+	w.WriteHeader(status)
+	io.WriteString(w, `{"message":"`+message+`"}`)
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":2}}`)
+}
+
+// newTestClient creates a client for endpoint with static credentials and no shared AWS config
+func newTestClient(t *testing.T, endpoint string, policy RetryPolicy) *Client {
+	t.Helper()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	client, err := NewClient(context.Background(),
+		WithEndpoint(endpoint),
+		WithRegion("us-east-1"),
+		WithRetryPolicy(policy),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+var question = []types.Message{{Role: "user", Content: "q"}}
+
+func TestRetryThrottled(t *testing.T) {
+	endpoint, requests := fakeBedrock(t, func(w http.ResponseWriter, n int) {
+		if n == 1 {
+			writeError(w, http.StatusTooManyRequests, "ThrottlingException", "Too many requests, please wait before trying again.")
+			return
+		}
+		writeOK(w)
+	})
+
+	var retried []error
+	client := newTestClient(t, endpoint, RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			retried = append(retried, err)
+		},
+	})
+
+	result, err := client.InvokeModel(context.Background(), "", question)
+	if err != nil {
+		t.Fatalf("InvokeModel: %v", err)
+	}
+	if result.Text != "ok" {
+		t.Errorf("Text = %q, want ok", result.Text)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+	if len(retried) != 1 || !errors.Is(retried[0], ErrThrottled) {
+		t.Errorf("OnRetry errors = %v, want one ErrThrottled", retried)
+	}
+}
+
+func TestRetryThrottledExhausted(t *testing.T) {
+	endpoint, requests := fakeBedrock(t, func(w http.ResponseWriter, n int) {
+		writeError(w, http.StatusTooManyRequests, "ThrottlingException", "Too many requests")
+	})
+	client := newTestClient(t, endpoint, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := client.InvokeModel(context.Background(), "", question)
+	if !errors.Is(err, ErrThrottled) {
+		t.Fatalf("error = %v, want ErrThrottled", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "ThrottlingException" || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("APIError = %+v, want ThrottlingException with status 429", apiErr)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestContextLengthNotRetried(t *testing.T) {
+	endpoint, requests := fakeBedrock(t, func(w http.ResponseWriter, n int) {
+		writeError(w, http.StatusBadRequest, "ValidationException", "Input is too long for requested model.")
+	})
+	client := newTestClient(t, endpoint, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := client.InvokeModel(context.Background(), "", question)
+	if !errors.Is(err, ErrContextLength) {
+		t.Fatalf("error = %v, want ErrContextLength", err)
+	}
+	if errors.Is(err, ErrValidation) {
+		t.Errorf("error also matches ErrValidation; context length should be its own kind")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1 without retries", got)
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	endpoint, requests := fakeBedrock(t, func(w http.ResponseWriter, n int) {
+		writeError(w, http.StatusServiceUnavailable, "ServiceUnavailableException", "Service unavailable")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newTestClient(t, endpoint, RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			cancel()
+		},
+	})
+
+	start := time.Now()
+	_, err := client.InvokeModel(ctx, "", question)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("InvokeModel took %s; cancellation should end the backoff", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("error = %v, want the last failure ErrUnavailable as well", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
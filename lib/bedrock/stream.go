@@ -0,0 +1,102 @@
+package bedrock
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	brtypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+var _ llm.Streamer = (*Client)(nil)
+
+// InvokeModelStream sends messages to Claude and passes text deltas to fn as they arrive.
+// Generation is stopped early when fn returns false; the result then holds the text received so far.
+func (c *Client) InvokeModelStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*InvokeResult, error) {
+	requestBody, err := json.Marshal(c.newRequest(systemPrompt, messages))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var output *bedrockruntime.InvokeModelWithResponseStreamOutput
+	reservation, err := c.send(ctx, requestBody, func() error {
+		var err error
+		output, err = c.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+			ModelId:     aws.String(c.modelID),
+			ContentType: aws.String("application/json"),
+			Body:        requestBody,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke model: %w", err)
+	}
+
+	result := &InvokeResult{Model: c.modelID}
+	defer func() {
+		reservation.Done(result.InputTokens + result.OutputTokens)
+	}()
+
+	stream := output.GetStream()
+	defer stream.Close()
+
+	var text strings.Builder
+
+	for event := range stream.Events() {
+		chunk, ok := event.(*brtypes.ResponseStreamMemberChunk)
+		if !ok {
+			continue
+		}
+
+		var e types.StreamEvent
+		if err := json.Unmarshal(chunk.Value.Bytes, &e); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+
+		switch e.Type {
+		case "message_start":
+			result.Model = cmp.Or(e.Message.Model, c.modelID)
+			result.SetUsage(e.Message.Usage)
+		case "content_block_delta":
+			if e.Delta.Type != "text_delta" {
+				continue
+			}
+			text.WriteString(e.Delta.Text)
+			if !fn(e.Delta.Text) {
+				result.StopStream(text.String())
+				return finishStream(result, text.String()), nil
+			}
+		case "message_delta":
+			result.StopReason = e.Delta.StopReason
+			result.OutputTokens = e.Usage.OutputTokens
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	return finishStream(result, text.String()), nil
+}
+
+// finishStream sets the streamed text on result. Only text deltas are streamed,
+// so Content holds a single text block.
+func finishStream(result *InvokeResult, text string) *InvokeResult {
+	result.Text = text
+	result.Content = []types.ContentBlock{{Type: types.BlockText, Text: text}}
+	return result
+}
+
+// CompleteStream implements llm.Streamer
+func (c *Client) CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*llm.Result, error) {
+	return c.InvokeModelStream(ctx, systemPrompt, messages, fn)
+}
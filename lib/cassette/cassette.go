@@ -0,0 +1,243 @@
+// Package cassette records model calls to a JSONL file and replays them offline
+package cassette
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// Mode selects whether a cassette records or replays
+type Mode string
+
+const (
+	// ModeRecord forwards calls to the real model and appends each pair to the file
+	ModeRecord Mode = "record"
+	// ModeReplay answers calls from the file without touching the real model
+	ModeReplay Mode = "replay"
+)
+
+// ErrNotRecorded is returned in replay mode when no entry matches a request
+var ErrNotRecorded = errors.New("cassette: request not recorded")
+
+// Entry is one recorded request/response pair, stored as a line of JSONL
+type Entry struct {
+	Key          string          `json:"key"`
+	SystemPrompt string          `json:"system"`
+	Messages     []types.Message `json:"messages"`
+	Tools        []llm.ToolSpec  `json:"tools,omitempty"`
+	Result       llm.Result      `json:"result"`
+}
+
+// Cassette wraps an llm.LLM with record or replay behavior
+type Cassette struct {
+	mode Mode
+	next llm.LLM
+
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string][]llm.Result
+}
+
+var (
+	_ llm.LLM        = (*Cassette)(nil)
+	_ llm.ToolCaller = (*Cassette)(nil)
+	_ llm.Streamer   = (*Cassette)(nil)
+)
+
+// Open opens the cassette at path. In record mode next receives every call;
+// in replay mode next is unused and may be nil.
+func Open(path string, mode Mode, next llm.LLM) (*Cassette, error) {
+	c := &Cassette{mode: mode, next: next}
+
+	switch mode {
+	case ModeRecord:
+		if next == nil {
+			return nil, fmt.Errorf("cassette: record mode requires a model")
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open cassette %s: %w", path, err)
+		}
+		c.file = file
+
+	case ModeReplay:
+		entries, err := load(path)
+		if err != nil {
+			return nil, err
+		}
+		c.entries = entries
+
+	default:
+		return nil, fmt.Errorf("cassette: unknown mode %q", mode)
+	}
+
+	return c, nil
+}
+
+// Complete implements llm.LLM
+func (c *Cassette) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	return c.do(systemPrompt, messages, nil, func() (*llm.Result, error) {
+		return c.next.Complete(ctx, systemPrompt, messages)
+	})
+}
+
+// CompleteWithTools implements llm.ToolCaller; record mode requires the wrapped model to support it
+func (c *Cassette) CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*llm.Result, error) {
+	return c.do(systemPrompt, messages, tools, func() (*llm.Result, error) {
+		caller, ok := c.next.(llm.ToolCaller)
+		if !ok {
+			return nil, fmt.Errorf("model %T does not support native tool calling", c.next)
+		}
+		return caller.CompleteWithTools(ctx, systemPrompt, messages, tools)
+	})
+}
+
+// CompleteStream implements llm.Streamer. The final result is recorded, including a
+// reply the caller stopped early, and replayed line by line as deltas. A wrapped model
+// that cannot stream is called with Complete and its reply delivered the same way.
+func (c *Cassette) CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*llm.Result, error) {
+	streamed := false
+	result, err := c.do(systemPrompt, messages, nil, func() (*llm.Result, error) {
+		streamer, ok := c.next.(llm.Streamer)
+		if !ok {
+			return c.next.Complete(ctx, systemPrompt, messages)
+		}
+		streamed = true
+		return streamer.CompleteStream(ctx, systemPrompt, messages, fn)
+	})
+	if err != nil || streamed {
+		return result, err
+	}
+	return replayStream(result, fn), nil
+}
+
+// replayStream passes the text of result to fn one line at a time
+func replayStream(result *llm.Result, fn llm.StreamFunc) *llm.Result {
+	sent := 0
+	for _, line := range strings.SplitAfter(result.Text, "\n") {
+		if line == "" {
+			continue
+		}
+		sent += len(line)
+		if !fn(line) {
+			result.Text = result.Text[:sent]
+			result.StopStream(result.Text)
+			break
+		}
+	}
+	return result
+}
+
+func (c *Cassette) do(systemPrompt string, messages []types.Message, tools []llm.ToolSpec, call func() (*llm.Result, error)) (*llm.Result, error) {
+	key, err := Key(systemPrompt, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode == ModeReplay {
+		return c.replay(key)
+	}
+
+	result, err := call()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.record(Entry{
+		Key:          key,
+		SystemPrompt: systemPrompt,
+		Messages:     messages,
+		Tools:        tools,
+		Result:       *result,
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close closes the underlying file in record mode
+func (c *Cassette) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+func (c *Cassette) replay(key string) (*llm.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := c.entries[key]
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w (key %s)", ErrNotRecorded, key)
+	}
+	// Identical requests are replayed in the order they were recorded
+	result := results[0]
+	c.entries[key] = results[1:]
+	return &result, nil
+}
+
+func (c *Cassette) record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write cassette entry: %w", err)
+	}
+	return nil
+}
+
+// Key returns the hash identifying a request in the cassette
+func Key(systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (string, error) {
+	body, err := json.Marshal(struct {
+		System   string          `json:"system"`
+		Messages []types.Message `json:"messages"`
+		Tools    []llm.ToolSpec  `json:"tools,omitempty"`
+	}{systemPrompt, messages, tools})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cassette key: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func load(path string) (map[string][]llm.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette %s: %w", path, err)
+	}
+	defer file.Close()
+
+	entries := make(map[string][]llm.Result)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s line %d: %w", path, line, err)
+		}
+		entries[entry.Key] = append(entries[entry.Key], entry.Result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,125 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/llmtest"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+var (
+	question = []types.Message{{Role: "user", Content: "q"}}
+	other    = []types.Message{{Role: "user", Content: "other"}}
+	lookup   = []llm.ToolSpec{{Name: "Lookup", Description: "Looks up a value", InputSchema: llm.NoInputSchema}}
+)
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	model := &llmtest.Model{}
+	model.Push(
+		llmtest.Reply{Text: "first", InputTokens: 10, OutputTokens: 1},
+		llmtest.Reply{Text: "second"},
+		llmtest.ToolUse("toolu_1", "Lookup", `{"key":"a"}`),
+	)
+
+	recorder, err := Open(path, ModeRecord, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, want := range []string{"first", "second"} {
+		result, err := recorder.Complete(ctx, "system", question)
+		if err != nil || result.Text != want {
+			t.Fatalf("record Complete = %v, %v, want %q", result, err, want)
+		}
+	}
+	if _, err := recorder.CompleteWithTools(ctx, "system", other, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	player, err := Open(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same request is answered in the order it was recorded
+	for _, want := range []string{"first", "second"} {
+		result, err := player.Complete(ctx, "system", question)
+		if err != nil || result.Text != want {
+			t.Fatalf("replay Complete = %v, %v, want %q", result, err, want)
+		}
+	}
+	result, err := player.CompleteWithTools(ctx, "system", other, lookup)
+	if err != nil || len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "Lookup" {
+		t.Fatalf("replay CompleteWithTools = %+v, %v, want the Lookup call", result, err)
+	}
+
+	if _, err := player.Complete(ctx, "system", question); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("third replay error = %v, want ErrNotRecorded", err)
+	}
+	if _, err := player.Complete(ctx, "another system", other); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("unknown request error = %v, want ErrNotRecorded", err)
+	}
+	if got := len(model.Calls()); got != 3 {
+		t.Errorf("model got %d calls, want 3 during recording only", got)
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	text := llmtest.Action("Look it up", "Lookup", "a")
+	model := llmtest.New(text)
+
+	recorder, err := Open(path, ModeRecord, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live strings.Builder
+	if _, err := recorder.CompleteStream(context.Background(), "system", question, func(delta string) bool {
+		live.WriteString(delta)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Close()
+	if live.String() != text {
+		t.Errorf("recorded stream = %q, want %q", live.String(), text)
+	}
+
+	// A replayed stream arrives line by line
+	player, err := Open(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	result, err := player.CompleteStream(context.Background(), "system", question, func(delta string) bool {
+		deltas = append(deltas, delta)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 3 || strings.Join(deltas, "") != text || result.Text != text {
+		t.Errorf("deltas = %q, Text = %q, want the recorded text in three lines", deltas, result.Text)
+	}
+
+	// and can be stopped like a live one
+	player, err = Open(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = player.CompleteStream(context.Background(), "system", question, func(string) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "Thought: Look it up\n" || result.StopReason != llm.StopSequence {
+		t.Errorf("Text, StopReason = %q, %q, want the first line and a stop", result.Text, result.StopReason)
+	}
+}
@@ -0,0 +1,45 @@
+package cassette
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// Environment variables read by FromEnv
+const (
+	EnvPath = "REACT_CASSETTE"
+	EnvMode = "REACT_CASSETTE_MODE"
+)
+
+// FromEnv wraps the model returned by newModel in a cassette when REACT_CASSETTE is set.
+// REACT_CASSETTE_MODE selects "record" or "replay" (default). newModel is not called
+// in replay mode, so replaying needs no credentials.
+func FromEnv(newModel func() (llm.LLM, error)) (llm.LLM, func() error, error) {
+	path := os.Getenv(EnvPath)
+	if path == "" {
+		model, err := newModel()
+		return model, func() error { return nil }, err
+	}
+
+	mode := Mode(os.Getenv(EnvMode))
+	if mode == "" {
+		mode = ModeReplay
+	}
+
+	var next llm.LLM
+	if mode != ModeReplay {
+		model, err := newModel()
+		if err != nil {
+			return nil, nil, err
+		}
+		next = model
+	}
+
+	c, err := Open(path, mode, next)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	return c, c.Close, nil
+}
@@ -0,0 +1,63 @@
+// Package envvar reads flag defaults from environment variables. Int, Float, Bool and
+// Set report a malformed value on stderr and keep the default; settings that must not
+// fall back, like budgets, use Lookup and fail on the error.
+package envvar
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Lookup parses the environment variable key with parse, returning def when it is
+// unset or empty and an error naming the variable when it does not parse
+func Lookup[T any](key string, def T, parse func(string) (T, error)) (T, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return def, nil
+	}
+	v, err := parse(s)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s=%q: %w", key, s, err)
+	}
+	return v, nil
+}
+
+// Int returns the integer in key, or def when it is unset or malformed
+func Int(key string, def int) int {
+	return orDefault(key, def, strconv.Atoi)
+}
+
+// Float returns the number in key, or def when it is unset or malformed
+func Float(key string, def float64) float64 {
+	return orDefault(key, def, ParseFloat)
+}
+
+// Bool returns the boolean in key, or def when it is unset or malformed
+func Bool(key string, def bool) bool {
+	return orDefault(key, def, strconv.ParseBool)
+}
+
+// Set sets v from key when it is set, keeping v's default when the value is malformed
+func Set(key string, v flag.Value) {
+	if s := os.Getenv(key); s != "" {
+		if err := v.Set(s); err != nil {
+			log.Printf("ignoring invalid %s=%q: %v", key, s, err)
+		}
+	}
+}
+
+// ParseFloat parses a float64 for Lookup
+func ParseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func orDefault[T any](key string, def T, parse func(string) (T, error)) T {
+	v, err := Lookup(key, def, parse)
+	if err != nil {
+		log.Printf("ignoring %v", err)
+	}
+	return v
+}
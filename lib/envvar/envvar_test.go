@@ -0,0 +1,72 @@
+package envvar
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	t.Setenv("TEST_ENVVAR_SET", "3")
+	t.Setenv("TEST_ENVVAR_BAD", "abc")
+	t.Setenv("TEST_ENVVAR_EMPTY", "")
+
+	if v, err := Lookup("TEST_ENVVAR_SET", 7, strconv.Atoi); v != 3 || err != nil {
+		t.Errorf("Lookup(set) = %d, %v; want 3, nil", v, err)
+	}
+	if v, err := Lookup("TEST_ENVVAR_EMPTY", 7, strconv.Atoi); v != 7 || err != nil {
+		t.Errorf("Lookup(empty) = %d, %v; want 7, nil", v, err)
+	}
+	if v, err := Lookup("TEST_ENVVAR_UNSET", 7, strconv.Atoi); v != 7 || err != nil {
+		t.Errorf("Lookup(unset) = %d, %v; want 7, nil", v, err)
+	}
+	v, err := Lookup("TEST_ENVVAR_BAD", 7, strconv.Atoi)
+	if v != 7 || err == nil || !strings.Contains(err.Error(), `TEST_ENVVAR_BAD="abc"`) {
+		t.Errorf("Lookup(bad) = %d, %v; want 7 and an error naming the variable", v, err)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	t.Setenv("TEST_ENVVAR_INT", "x")
+	t.Setenv("TEST_ENVVAR_FLOAT", "0.5")
+	t.Setenv("TEST_ENVVAR_BOOL", "maybe")
+
+	if v := Int("TEST_ENVVAR_INT", 4); v != 4 {
+		t.Errorf("Int(malformed) = %d, want the default 4", v)
+	}
+	if v := Float("TEST_ENVVAR_FLOAT", -1); v != 0.5 {
+		t.Errorf("Float = %v, want 0.5", v)
+	}
+	if v := Bool("TEST_ENVVAR_BOOL", true); !v {
+		t.Error("Bool(malformed) = false, want the default true")
+	}
+}
+
+type durationValue struct{ d time.Duration }
+
+func (v *durationValue) String() string { return v.d.String() }
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	v.d = d
+	return nil
+}
+
+func TestSet(t *testing.T) {
+	v := &durationValue{d: time.Minute}
+	t.Setenv("TEST_ENVVAR_DURATION", "nope")
+	Set("TEST_ENVVAR_DURATION", v)
+	if v.d != time.Minute {
+		t.Errorf("Set(malformed) = %v, want the default 1m0s", v.d)
+	}
+
+	t.Setenv("TEST_ENVVAR_DURATION", "2s")
+	Set("TEST_ENVVAR_DURATION", v)
+	if v.d != 2*time.Second {
+		t.Errorf("Set = %v, want 2s", v.d)
+	}
+}
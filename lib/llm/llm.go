@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// LLM is a chat model backend that the agents run on
+type LLM interface {
+	// Complete sends the system prompt and conversation to the model and returns its reply
+	Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*Result, error)
+}
+
+// ToolCaller is implemented by backends that support native tool calling
+type ToolCaller interface {
+	// CompleteWithTools is Complete with tool definitions the model may call
+	CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []ToolSpec) (*Result, error)
+}
+
+// StreamFunc receives text deltas as they are generated. Returning false stops generation.
+type StreamFunc func(delta string) bool
+
+// Streamer is implemented by backends that can stream their reply
+type Streamer interface {
+	// CompleteStream is Complete with text deltas passed to fn as they arrive
+	CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn StreamFunc) (*Result, error)
+}
+
+// Result is a single model reply
+type Result struct {
+	// Text is the concatenation of all text blocks
+	Text      string     `json:"text"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// Content holds every content block of the reply in order, when the backend reports them
+	Content    []types.ContentBlock `json:"content,omitempty"`
+	StopReason string               `json:"stop_reason,omitempty"`
+	Model      string               `json:"model,omitempty"`
+
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+}
+
+// Stop reasons reported in Result.StopReason
+const (
+	StopEndTurn   = "end_turn"
+	StopMaxTokens = "max_tokens"
+	StopSequence  = "stop_sequence"
+	StopToolUse   = "tool_use"
+	StopPauseTurn = "pause_turn"
+	StopRefusal   = "refusal"
+)
+
+// Truncated reports whether generation was cut off by the output token limit
+func (r *Result) Truncated() bool {
+	return r.StopReason == StopMaxTokens
+}
+
+// SetUsage copies the token counts of an Anthropic usage object into r
+func (r *Result) SetUsage(u types.Usage) {
+	r.InputTokens = u.InputTokens
+	r.OutputTokens = u.OutputTokens
+	r.CacheReadInputTokens = u.CacheReadInputTokens
+	r.CacheCreationInputTokens = u.CacheCreationInputTokens
+}
+
+// SetContent sets the content blocks of an Anthropic reply on r, joining the text
+// blocks into Text and collecting the tool_use blocks as ToolCalls
+func (r *Result) SetContent(blocks []types.ContentBlock) {
+	r.Content = blocks
+	var text []string
+	for _, block := range blocks {
+		switch block.Type {
+		case types.BlockText:
+			text = append(text, block.Text)
+		case types.BlockToolUse:
+			r.ToolCalls = append(r.ToolCalls, ToolCall{
+				ID:    block.ID,
+				Name:  block.Name,
+				Input: block.Input,
+			})
+		}
+	}
+	r.Text = strings.Join(text, "\n")
+}
+
+// StopStream records on r that the caller stopped a streamed reply after text. The
+// final usage event never arrives then, so the output tokens are estimated from text
+// and the stop is reported like a stop sequence.
+func (r *Result) StopStream(text string) {
+	r.StopReason = StopSequence
+	r.OutputTokens = max(r.OutputTokens, EstimateTokens(text))
+}
+
+// EstimateTokens approximates the number of tokens in text at four bytes per token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// ToolSpec describes a tool the model may call natively
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ToolCall is a tool invocation requested by the model
+type ToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
@@ -0,0 +1,44 @@
+package llm
+
+import "encoding/json"
+
+// NoInputSchema is the JSON schema of a tool that takes no arguments
+var NoInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// StringInputSchema returns the JSON schema of a tool taking a single required string argument
+func StringInputSchema(name, description string) json.RawMessage {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			name: map[string]any{
+				"type":        "string",
+				"description": description,
+			},
+		},
+		"required": []string{name},
+	}
+	raw, _ := json.Marshal(schema)
+	return raw
+}
+
+// InputText flattens native tool input to the string form used by text actions.
+// An object with a single string field yields that string, an empty object yields
+// "", and anything else is returned as raw JSON.
+func InputText(input json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return string(input)
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	if len(fields) == 1 {
+		for _, raw := range fields {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				return s
+			}
+		}
+	}
+	return string(input)
+}
@@ -0,0 +1,156 @@
+// Package llmtest provides a scripted llm.LLM for running agents offline
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// ErrScriptExhausted is returned when the model is called more times than it has replies
+var ErrScriptExhausted = errors.New("llmtest: no scripted replies left")
+
+// Reply is one canned model turn
+type Reply struct {
+	Text         string
+	ToolCalls    []llm.ToolCall
+	StopReason   string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	// Err is returned instead of a result when set
+	Err error
+}
+
+// Call records the input of one Complete call
+type Call struct {
+	SystemPrompt string
+	Messages     []types.Message
+	// Tools is set for CompleteWithTools calls
+	Tools []llm.ToolSpec
+}
+
+// Model replays a queue of canned replies and records the messages it received
+type Model struct {
+	mu      sync.Mutex
+	replies []Reply
+	calls   []Call
+}
+
+var (
+	_ llm.LLM        = (*Model)(nil)
+	_ llm.ToolCaller = (*Model)(nil)
+	_ llm.Streamer   = (*Model)(nil)
+)
+
+// streamChunkSize is the number of bytes per delta in CompleteStream
+const streamChunkSize = 4
+
+// New creates a Model that replies with the given texts in order
+func New(texts ...string) *Model {
+	m := &Model{}
+	for _, text := range texts {
+		m.Push(Reply{Text: text})
+	}
+	return m
+}
+
+// Push appends replies to the end of the script
+func (m *Model) Push(replies ...Reply) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies = append(m.replies, replies...)
+}
+
+// Complete implements llm.LLM by popping the next scripted reply
+func (m *Model) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	return m.next(ctx, Call{
+		SystemPrompt: systemPrompt,
+		Messages:     append([]types.Message(nil), messages...),
+	})
+}
+
+// CompleteWithTools implements llm.ToolCaller by popping the next scripted reply
+func (m *Model) CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*llm.Result, error) {
+	return m.next(ctx, Call{
+		SystemPrompt: systemPrompt,
+		Messages:     append([]types.Message(nil), messages...),
+		Tools:        tools,
+	})
+}
+
+// CompleteStream implements llm.Streamer by delivering the next scripted reply in small chunks
+func (m *Model) CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*llm.Result, error) {
+	result, err := m.Complete(ctx, systemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	text := result.Text
+	for i := 0; i < len(text); i += streamChunkSize {
+		end := min(i+streamChunkSize, len(text))
+		if !fn(text[i:end]) {
+			result.Text = text[:end]
+			result.StopStream(result.Text)
+			break
+		}
+	}
+	return result, nil
+}
+
+func (m *Model) next(ctx context.Context, call Call) (*llm.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, call)
+
+	if len(m.replies) == 0 {
+		return nil, fmt.Errorf("%w (call %d)", ErrScriptExhausted, len(m.calls))
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+	return &llm.Result{
+		Text:         reply.Text,
+		ToolCalls:    reply.ToolCalls,
+		StopReason:   reply.StopReason,
+		Model:        reply.Model,
+		InputTokens:  reply.InputTokens,
+		OutputTokens: reply.OutputTokens,
+	}, nil
+}
+
+// Calls returns the inputs of every Complete call so far
+func (m *Model) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// LastCall returns the input of the most recent Complete call
+func (m *Model) LastCall() (Call, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.calls) == 0 {
+		return Call{}, false
+	}
+	return m.calls[len(m.calls)-1], true
+}
+
+// Remaining returns the number of replies not yet consumed
+func (m *Model) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.replies)
+}
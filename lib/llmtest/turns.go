@@ -0,0 +1,25 @@
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// Action formats a Thought/Action/Action Input turn
+func Action(thought, action, input string) string {
+	return fmt.Sprintf("Thought: %s\nAction: %s\nAction Input: %s", thought, action, input)
+}
+
+// FinalAnswer formats a Thought/Final Answer turn
+func FinalAnswer(thought, answer string) string {
+	return fmt.Sprintf("Thought: %s\nFinal Answer: %s", thought, answer)
+}
+
+// ToolUse returns a reply that calls one tool natively with the given JSON input
+func ToolUse(id, name, input string) Reply {
+	return Reply{
+		ToolCalls: []llm.ToolCall{{ID: id, Name: name, Input: json.RawMessage(input)}},
+	}
+}
@@ -0,0 +1,163 @@
+// Package openai is an llm.LLM backend for servers exposing the OpenAI chat
+// completions API, such as llama.cpp, vLLM and Ollama
+package openai
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/ratelimit"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+const defaultMaxTokens = 4096
+
+type Client struct {
+	httpClient    *http.Client
+	baseURL       string
+	apiKey        string
+	model         string
+	maxTokens     int
+	temperature   *float64
+	topP          *float64
+	stopSequences []string
+	limiter       *ratelimit.Limiter
+}
+
+var (
+	_ llm.LLM        = (*Client)(nil)
+	_ llm.ToolCaller = (*Client)(nil)
+	_ llm.Streamer   = (*Client)(nil)
+)
+
+// NewClient creates a client for the chat completions API under baseURL, e.g. http://localhost:8080/v1
+func NewClient(baseURL, model string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		maxTokens:  defaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Complete implements llm.LLM
+func (c *Client) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	return c.complete(ctx, c.newRequest(systemPrompt, messages, nil))
+}
+
+// CompleteWithTools implements llm.ToolCaller using function calling
+func (c *Client) CompleteWithTools(ctx context.Context, systemPrompt string, messages []types.Message, tools []llm.ToolSpec) (*llm.Result, error) {
+	return c.complete(ctx, c.newRequest(systemPrompt, messages, tools))
+}
+
+func (c *Client) complete(ctx context.Context, request chatRequest) (*llm.Result, error) {
+	body, reservation, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var response chatResponse
+	err = json.NewDecoder(body).Decode(&response)
+	reservation.Done(response.Usage.PromptTokens + response.Usage.CompletionTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	choice := response.Choices[0]
+	result := &llm.Result{
+		Text:       choice.Message.Content,
+		StopReason: stopReason(choice.FinishReason),
+		Model:      cmp.Or(response.Model, c.model),
+	}
+	response.Usage.apply(result)
+
+	if result.Text != "" {
+		result.Content = append(result.Content, types.ContentBlock{Type: types.BlockText, Text: result.Text})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		result.ToolCalls = append(result.ToolCalls, llm.ToolCall{
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: input,
+		})
+		result.Content = append(result.Content, types.ContentBlock{
+			Type:  types.BlockToolUse,
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: input,
+		})
+	}
+
+	return result, nil
+}
+
+// post sends a chat completions request and returns the response body on HTTP 200.
+// The caller must complete the reservation with the tokens actually used.
+func (c *Client) post(ctx context.Context, request chatRequest) (io.ReadCloser, *ratelimit.Reservation, error) {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	reservation, err := c.limiter.Acquire(ctx, len(requestBody)/4+c.maxTokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		reservation.Done(0)
+		return nil, nil, fmt.Errorf("failed to invoke model: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		reservation.Done(0)
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, nil, fmt.Errorf("failed to invoke model: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp.Body, reservation, nil
+}
+
+// stopReason maps an OpenAI finish_reason to the Anthropic stop reasons used by llm.Result
+func stopReason(finishReason string) string {
+	switch finishReason {
+	case "stop":
+		return llm.StopEndTurn
+	case "length":
+		return llm.StopMaxTokens
+	case "tool_calls", "function_call":
+		return llm.StopToolUse
+	case "content_filter":
+		return llm.StopRefusal
+	}
+	return finishReason
+}
@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// newTestClient starts a server running handler and returns a client pointed at it
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/v1/", "test-model", opts...)
+}
+
+func TestCompleteRequest(t *testing.T) {
+	var got chatRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	},
+		WithAPIKey("test-key"),
+		WithMaxTokens(100),
+		WithStopSequences("\nObservation:"),
+	)
+
+	tools := []llm.ToolSpec{{Name: "ReadFile", Description: "Reads a file", InputSchema: llm.StringInputSchema("filename", "File to read")}}
+	messages := []types.Message{
+		{Role: "user", Content: "question"},
+		{Role: "assistant", Blocks: []types.ContentBlock{
+			{Type: types.BlockThinking, Thinking: "hidden"},
+			{Type: types.BlockText, Text: "Let me read it"},
+			{Type: types.BlockToolUse, ID: "call_1", Name: "ReadFile", Input: json.RawMessage(`{"filename":"a.go"}`)},
+			{Type: types.BlockToolUse, ID: "call_2", Name: "ReadFile", Input: json.RawMessage(`{"filename":"b.go"}`)},
+		}},
+		{Role: "user", Blocks: []types.ContentBlock{
+			{Type: types.BlockToolResult, ToolUseID: "call_1", Content: "package a"},
+			{Type: types.BlockToolResult, ToolUseID: "call_2", Content: "package b", IsError: true},
+		}},
+	}
+	if _, err := client.CompleteWithTools(context.Background(), "system prompt", messages, tools); err != nil {
+		t.Fatalf("CompleteWithTools: %v", err)
+	}
+
+	if got.Model != "test-model" || got.MaxTokens != 100 || len(got.Stop) != 1 || got.Stream {
+		t.Errorf("model, max_tokens, stop, stream = %s, %d, %q, %v", got.Model, got.MaxTokens, got.Stop, got.Stream)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "ReadFile" {
+		t.Errorf("tools = %+v, want the ReadFile function", got.Tools)
+	}
+
+	var roles []string
+	for _, m := range got.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, " ") != "system user assistant tool tool" {
+		t.Fatalf("roles = %v, want system user assistant tool tool", roles)
+	}
+	assistant := got.Messages[2]
+	if assistant.Content != "Let me read it" || len(assistant.ToolCalls) != 2 {
+		t.Fatalf("assistant message = %+v, want the text and two tool_calls without thinking", assistant)
+	}
+	if call := assistant.ToolCalls[0]; call.ID != "call_1" || call.Type != "function" ||
+		call.Function.Name != "ReadFile" || call.Function.Arguments != `{"filename":"a.go"}` {
+		t.Errorf("tool call = %+v", call)
+	}
+	if tool := got.Messages[4]; tool.ToolCallID != "call_2" || tool.Content != "package b" {
+		t.Errorf("tool message = %+v, want the result of call_2", tool)
+	}
+}
+
+func TestCompleteResponse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{
+			"model": "served-model",
+			"choices": [{
+				"message": {"role": "assistant", "content": "", "tool_calls": [
+					{"id": "call_1", "type": "function", "function": {"name": "ReadFile", "arguments": "{\"filename\":\"a.go\"}"}},
+					{"id": "call_2", "type": "function", "function": {"name": "ListFiles", "arguments": ""}}
+				]},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 100, "completion_tokens": 20, "prompt_tokens_details": {"cached_tokens": 60}}
+		}`)
+	})
+
+	result, err := client.CompleteWithTools(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, nil)
+	if err != nil {
+		t.Fatalf("CompleteWithTools: %v", err)
+	}
+	if result.StopReason != llm.StopToolUse || result.Model != "served-model" {
+		t.Errorf("StopReason, Model = %s, %s", result.StopReason, result.Model)
+	}
+	// Cached tokens are part of prompt_tokens and must not be counted twice
+	if result.InputTokens != 40 || result.CacheReadInputTokens != 60 || result.OutputTokens != 20 {
+		t.Errorf("usage = %d/%d/%d, want input 40, cache read 60, output 20",
+			result.InputTokens, result.CacheReadInputTokens, result.OutputTokens)
+	}
+	if len(result.ToolCalls) != 2 || string(result.ToolCalls[0].Input) != `{"filename":"a.go"}` {
+		t.Fatalf("ToolCalls = %+v", result.ToolCalls)
+	}
+	if string(result.ToolCalls[1].Input) != "{}" {
+		t.Errorf("empty arguments = %s, want {}", result.ToolCalls[1].Input)
+	}
+	if len(result.Content) != 2 || result.Content[0].Type != types.BlockToolUse {
+		t.Errorf("Content = %+v, want two tool_use blocks", result.Content)
+	}
+}
+
+func TestStopReason(t *testing.T) {
+	tests := map[string]string{
+		"stop":           llm.StopEndTurn,
+		"length":         llm.StopMaxTokens,
+		"tool_calls":     llm.StopToolUse,
+		"function_call":  llm.StopToolUse,
+		"content_filter": llm.StopRefusal,
+		"other":          "other",
+	}
+	for finishReason, want := range tests {
+		if got := stopReason(finishReason); got != want {
+			t.Errorf("stopReason(%q) = %q, want %q", finishReason, got, want)
+		}
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"model not loaded"}}`, http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Complete(context.Background(), "", []types.Message{{Role: "user", Content: "q"}})
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "model not loaded") {
+		t.Errorf("error = %v, want the status and message", err)
+	}
+}
@@ -0,0 +1,137 @@
+package openai
+
+import (
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+type chatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []chatMessage  `json:"messages"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	Stop          []string       `json:"stop,omitempty"`
+	Tools         []chatTool     `json:"tools,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type toolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage usage `json:"usage"`
+}
+
+type usage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// apply copies the token counts into result. prompt_tokens includes the cached
+// tokens, which are reported separately like Anthropic's cache reads.
+func (u usage) apply(result *llm.Result) {
+	result.InputTokens = u.PromptTokens - u.PromptTokensDetails.CachedTokens
+	result.OutputTokens = u.CompletionTokens
+	result.CacheReadInputTokens = u.PromptTokensDetails.CachedTokens
+}
+
+func (c *Client) newRequest(systemPrompt string, messages []types.Message, tools []llm.ToolSpec) chatRequest {
+	request := chatRequest{
+		Model:       c.model,
+		Messages:    convertMessages(systemPrompt, messages),
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		TopP:        c.topP,
+		Stop:        c.stopSequences,
+	}
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, chatTool{
+			Type: "function",
+			Function: toolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+	return request
+}
+
+// convertMessages maps Anthropic-style messages to chat completions messages.
+// tool_use blocks become assistant tool_calls, each tool_result block becomes a
+// "tool" message, and thinking blocks are dropped.
+func convertMessages(systemPrompt string, messages []types.Message) []chatMessage {
+	var out []chatMessage
+	if systemPrompt != "" {
+		out = append(out, chatMessage{Role: "system", Content: systemPrompt})
+	}
+
+	for _, m := range messages {
+		if len(m.Blocks) == 0 {
+			out = append(out, chatMessage{Role: m.Role, Content: m.Content})
+			continue
+		}
+
+		msg := chatMessage{Role: m.Role}
+		var text []string
+		for _, block := range m.Blocks {
+			switch block.Type {
+			case types.BlockText:
+				text = append(text, block.Text)
+			case types.BlockToolUse:
+				call := toolCall{ID: block.ID, Type: "function"}
+				call.Function.Name = block.Name
+				call.Function.Arguments = string(block.Input)
+				msg.ToolCalls = append(msg.ToolCalls, call)
+			case types.BlockToolResult:
+				out = append(out, chatMessage{Role: "tool", ToolCallID: block.ToolUseID, Content: block.Content})
+			}
+		}
+		msg.Content = strings.Join(text, "\n")
+		if msg.Content != "" || len(msg.ToolCalls) > 0 {
+			out = append(out, msg)
+		}
+	}
+
+	return out
+}
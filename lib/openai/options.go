@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"net/http"
+
+	"github.com/toumakido/reAct/lib/ratelimit"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey sends the key as a bearer token; local servers usually need none
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithMaxTokens sets the maximum number of tokens to generate per call
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *Client) {
+		c.maxTokens = maxTokens
+	}
+}
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithTopP sets the nucleus sampling threshold
+func WithTopP(topP float64) Option {
+	return func(c *Client) {
+		c.topP = &topP
+	}
+}
+
+// WithStopSequences sets sequences that stop generation, e.g. "\nObservation:"
+func WithStopSequences(sequences ...string) Option {
+	return func(c *Client) {
+		c.stopSequences = sequences
+	}
+}
+
+// WithLimiter makes the client wait on a rate limiter before each call
+func WithLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
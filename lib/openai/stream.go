@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+type streamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *usage `json:"usage"`
+}
+
+// CompleteStream implements llm.Streamer using server-sent events.
+// Generation is stopped early when fn returns false.
+func (c *Client) CompleteStream(ctx context.Context, systemPrompt string, messages []types.Message, fn llm.StreamFunc) (*llm.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	request := c.newRequest(systemPrompt, messages, nil)
+	request.Stream = true
+	request.StreamOptions = &streamOptions{IncludeUsage: true}
+
+	body, reservation, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	result := &llm.Result{Model: c.model}
+	defer func() {
+		reservation.Done(result.InputTokens + result.OutputTokens)
+	}()
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			chunk.Usage.apply(result)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			result.StopReason = stopReason(choice.FinishReason)
+		}
+		if choice.Delta.Content == "" {
+			continue
+		}
+		text.WriteString(choice.Delta.Content)
+		if !fn(choice.Delta.Content) {
+			result.StopStream(text.String())
+			// Usage comes in the last chunk, so estimate the prompt from the request as well
+			if result.InputTokens == 0 {
+				if b, err := json.Marshal(request); err == nil {
+					result.InputTokens = llm.EstimateTokens(string(b))
+				}
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	result.Text = text.String()
+	result.Content = []types.ContentBlock{{Type: types.BlockText, Text: result.Text}}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// sse writes chunks in the server-sent event format of the chat completions API
+func sse(w http.ResponseWriter, chunks ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range chunks {
+		fmt.Fprintf(w, "data: %s\n\n", chunk)
+	}
+}
+
+func contentDelta(text string) string {
+	return fmt.Sprintf(`{"choices":[{"delta":{"content":%q},"finish_reason":null}]}`, text)
+}
+
+func TestCompleteStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var got chatRequest
+		json.NewDecoder(r.Body).Decode(&got)
+		if !got.Stream || got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+			t.Errorf("stream, stream_options = %v, %+v, want usage included", got.Stream, got.StreamOptions)
+		}
+		sse(w,
+			`{"model":"served-model","choices":[{"delta":{"role":"assistant"}}]}`,
+			contentDelta("Thought: read "),
+			contentDelta("the file"),
+			`{"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":50,"completion_tokens":7,"prompt_tokens_details":{"cached_tokens":40}}}`,
+			"[DONE]",
+			contentDelta("after done"),
+		)
+	})
+
+	var deltas []string
+	result, err := client.CompleteStream(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, func(delta string) bool {
+		deltas = append(deltas, delta)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Thought: read |the file" {
+		t.Errorf("deltas = %q, want nothing after [DONE]", deltas)
+	}
+	if result.Text != "Thought: read the file" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if result.StopReason != llm.StopEndTurn || result.Model != "served-model" {
+		t.Errorf("StopReason, Model = %s, %s", result.StopReason, result.Model)
+	}
+	if result.InputTokens != 10 || result.CacheReadInputTokens != 40 || result.OutputTokens != 7 {
+		t.Errorf("usage = %d/%d/%d, want input 10, cache read 40, output 7",
+			result.InputTokens, result.CacheReadInputTokens, result.OutputTokens)
+	}
+}
+
+func TestCompleteStreamStoppedEarly(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sse(w,
+			contentDelta(long),
+			contentDelta("never delivered"),
+			`{"choices":[],"usage":{"prompt_tokens":50,"completion_tokens":300}}`,
+			"[DONE]",
+		)
+	})
+
+	var deltas int
+	result, err := client.CompleteStream(context.Background(), "", []types.Message{{Role: "user", Content: "q"}}, func(string) bool {
+		deltas++
+		return false
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream: %v", err)
+	}
+
+	if deltas != 1 || result.Text != long {
+		t.Errorf("got %d deltas and %d bytes of text, want only the first delta", deltas, len(result.Text))
+	}
+	// The usage chunk is never read, so both counts are estimated
+	if result.StopReason != llm.StopSequence || result.OutputTokens != llm.EstimateTokens(long) {
+		t.Errorf("StopReason, OutputTokens = %s, %d, want %s, %d",
+			result.StopReason, result.OutputTokens, llm.StopSequence, llm.EstimateTokens(long))
+	}
+	if result.InputTokens == 0 {
+		t.Errorf("InputTokens = 0, want an estimate from the request")
+	}
+}
@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
+)
+
+const describePrompt = `You write API documentation for OpenAPI documents.
+You are given an HTTP route and the Go handler that serves it.
+Reply with only a JSON object: {"summary": "...", "description": "..."}
+- summary: what the operation does, under 10 words, no trailing period
+- description: 1-3 sentences on behavior, inputs and error cases, based only on the code`
+
+// Describe asks model to write the summary and description of every operation that
+// has handler source. Operations are described in path order so recorded cassettes
+// replay. Failures are returned as warnings and leave the operation undescribed.
+func Describe(ctx context.Context, model llm.LLM, doc *Document) ([]string, error) {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var warnings []string
+	for _, path := range paths {
+		item := doc.Paths[path]
+		methods := make([]string, 0, len(item))
+		for method := range item {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			op := item[method]
+			if op.source == "" {
+				continue
+			}
+			if err := usage.CheckBudget(ctx); err != nil {
+				return warnings, err
+			}
+			if err := describe(ctx, model, op); err != nil {
+				if ctx.Err() != nil {
+					return warnings, err
+				}
+				warnings = append(warnings, fmt.Sprintf("%s: %v", op.route, err))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func describe(ctx context.Context, model llm.LLM, op *Operation) error {
+	prompt := fmt.Sprintf("Route: %s\n\nHandler:\n```go\n%s\n```", op.route, op.source)
+	result, err := model.Complete(ctx, describePrompt, []types.Message{{Role: "user", Content: prompt}})
+	if err != nil {
+		return fmt.Errorf("failed to describe operation: %w", err)
+	}
+	usage.FromContext(ctx).Record("OpenAPI Describer", result)
+
+	// Tolerate prose or code fences around the object
+	text := result.Text
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object in reply %q", text)
+	}
+	var reply struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return fmt.Errorf("invalid reply: %w", err)
+	}
+	op.Summary = strings.TrimSpace(reply.Summary)
+	op.Description = strings.TrimSpace(reply.Description)
+	return nil
+}
@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/toumakido/reAct/lib/tools"
+)
+
+// pathParam matches mux path variables, with an optional pattern as in {id:[0-9]+}
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Generate builds an OpenAPI document for the server in fsys. Paths come from the
+// route table, request bodies from json.Decoder.Decode targets and responses from
+// json.Encoder.Encode arguments, http.Error and WriteHeader calls in each handler.
+// Warnings list the routes that could not be fully analyzed.
+func Generate(ctx context.Context, fsys *tools.FS, info Info) (*Document, []string, error) {
+	if _, ok := fsys.Dir(); !ok {
+		return nil, nil, errors.New("the data directory must be on disk")
+	}
+	routes, skipped, err := fsys.Routes()
+	if err != nil {
+		return nil, nil, err
+	}
+	program, err := tools.LoadProgram(ctx, fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g := &generator{
+		program: program,
+		schemas: newSchemas(typeDocs(program.Packages())),
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+		},
+		operationIDs: make(map[string]bool),
+	}
+	for _, file := range skipped {
+		g.warnf("skipped a file that does not parse: %s", file)
+	}
+	for _, route := range routes {
+		g.add(route)
+	}
+	if len(g.schemas.components) > 0 {
+		g.doc.Components.Schemas = g.schemas.components
+	}
+	return g.doc, g.warnings, nil
+}
+
+type generator struct {
+	program      *tools.Program
+	schemas      *schemas
+	doc          *Document
+	operationIDs map[string]bool
+	warnings     []string
+}
+
+func (g *generator) add(route tools.Route) {
+	if route.Method == "" {
+		g.warnf("%s:%d: skipped %s, which accepts any method", route.File, route.Line, route.Path)
+		return
+	}
+	method := strings.ToLower(route.Method)
+	path := pathParam.ReplaceAllString(route.Path, "{$1}")
+
+	op := &Operation{
+		Responses: make(map[string]*Response),
+		route:     route.Method + " " + path,
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	pkg, decl := g.handler(route.Handler)
+	if decl == nil {
+		g.warnf("%s:%d: handler %s of %s %s not found; responses are unknown", route.File, route.Line, route.Handler, route.Method, path)
+		op.Responses["default"] = &Response{Description: "Unknown response"}
+	} else {
+		op.OperationID = g.operationID(decl.Name.Name, method)
+		op.source = g.source(pkg.Fset, decl)
+		g.analyze(pkg, decl, op)
+	}
+
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = make(PathItem)
+	}
+	g.doc.Paths[path][method] = op
+}
+
+// handler finds the declaration of a handler function such as handler.GetUsers
+func (g *generator) handler(expr string) (*packages.Package, *ast.FuncDecl) {
+	obj, err := g.program.Lookup(expr)
+	if err != nil {
+		return nil, nil
+	}
+	for _, pkg := range g.program.Packages() {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil && pkg.TypesInfo.Defs[fn.Name] == obj {
+					return pkg, fn
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// analyze fills the request body and responses from the calls in a handler body
+func (g *generator) analyze(pkg *packages.Package, decl *ast.FuncDecl, op *Operation) {
+	g.walk(pkg.TypesInfo, decl.Body.List, http.StatusOK, op)
+
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+}
+
+// walk visits the calls of a statement list in source order. The status set by
+// WriteHeader lasts until the end of its block, so an Encode after an error branch
+// such as if err != nil { w.WriteHeader(400); ...; return } keeps the outer status.
+func (g *generator) walk(info *types.Info, stmts []ast.Stmt, status int, op *Operation) {
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				g.walk(info, n.List, status, op)
+				return false
+			case *ast.CaseClause:
+				g.walk(info, n.Body, status, op)
+				return false
+			case *ast.CommClause:
+				g.walk(info, n.Body, status, op)
+				return false
+			case *ast.CallExpr:
+				status = g.call(info, n, status, op)
+			}
+			return true
+		})
+	}
+}
+
+// call records what a single call contributes to op and returns the status for
+// the calls after it
+func (g *generator) call(info *types.Info, call *ast.CallExpr, status int, op *Operation) int {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok {
+		return status
+	}
+
+	switch fn.FullName() {
+	case "(*encoding/json.Decoder).Decode":
+		if len(call.Args) == 1 {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(g.schemas.of(deref(info.TypeOf(call.Args[0])))),
+			}
+		}
+
+	case "(*encoding/json.Encoder).Encode":
+		if len(call.Args) == 1 {
+			op.Responses[strconv.Itoa(status)] = &Response{
+				Description: http.StatusText(status),
+				Content:     jsonContent(g.schemas.of(info.TypeOf(call.Args[0]))),
+			}
+		}
+
+	case "(net/http.ResponseWriter).WriteHeader":
+		if code, ok := intConstant(info, call.Args[0]); ok {
+			status = code
+			if op.Responses[strconv.Itoa(code)] == nil {
+				op.Responses[strconv.Itoa(code)] = &Response{Description: http.StatusText(code)}
+			}
+		}
+
+	case "net/http.Error":
+		if len(call.Args) == 3 {
+			if code, ok := intConstant(info, call.Args[2]); ok && op.Responses[strconv.Itoa(code)] == nil {
+				op.Responses[strconv.Itoa(code)] = &Response{
+					Description: http.StatusText(code),
+					Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+				}
+			}
+		}
+	}
+	return status
+}
+
+func (g *generator) operationID(name, method string) string {
+	id := name
+	if g.operationIDs[id] {
+		id = name + strings.ToUpper(method[:1]) + method[1:]
+	}
+	g.operationIDs[id] = true
+	return id
+}
+
+func (g *generator) warnf(format string, args ...any) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+func deref(t types.Type) types.Type {
+	if ptr, ok := t.(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return t
+}
+
+func intConstant(info *types.Info, expr ast.Expr) (int, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	code, ok := constant.Int64Val(tv.Value)
+	return int(code), ok
+}
+
+// source returns the text of a declaration
+func (g *generator) source(fset *token.FileSet, decl *ast.FuncDecl) string {
+	start, end := fset.Position(decl.Pos()), fset.Position(decl.End())
+	content, err := g.program.ReadFile(start.Filename)
+	if err != nil || end.Offset > len(content) {
+		return ""
+	}
+	return content[start.Offset:end.Offset]
+}
+
+// typeDocs indexes the doc comments of named types
+func typeDocs(pkgs []*packages.Package) map[*types.TypeName]string {
+	docs := make(map[*types.TypeName]string)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					spec := spec.(*ast.TypeSpec)
+					doc := spec.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					if tn, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName); ok && doc != nil {
+						docs[tn] = strings.TrimSpace(doc.Text())
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llmtest"
+	"github.com/toumakido/reAct/lib/tools"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// generate runs Generate on dir and fails the test on errors
+func generate(t *testing.T, dir string) (*Document, []string) {
+	t.Helper()
+	fsys, err := tools.OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	doc, warnings, err := Generate(context.Background(), fsys, Info{Title: "Test API", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return doc, warnings
+}
+
+func TestGenerateSampleServer(t *testing.T) {
+	doc, warnings := generate(t, "../../03-api-server-react/data")
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	// Describe visits operations in path order, so the numbers are stable
+	model := &llmtest.Model{}
+	for i := 1; i <= 7; i++ {
+		model.Push(llmtest.Reply{Text: fmt.Sprintf(`{"summary": "Summary %d", "description": "Description %d."}`, i, i)})
+	}
+	describeWarnings, err := Describe(context.Background(), model, doc)
+	if err != nil || len(describeWarnings) != 0 {
+		t.Fatalf("Describe: %v, warnings %v", err, describeWarnings)
+	}
+	if prompt := model.Calls()[0].Messages[0].Content; !strings.HasPrefix(prompt, "Route: GET /api/products\n") || !strings.Contains(prompt, "func GetProducts(") {
+		t.Errorf("first prompt = %q, want the route and handler source", prompt)
+	}
+
+	got, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "api-server.json")
+	if *update {
+		if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got)+"\n" != string(want) {
+		t.Errorf("document differs from %s; run go test -update to see the change:\n%s", golden, got)
+	}
+}
+
+func TestGenerateEdgeCases(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/edge\n\ngo 1.22\n",
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type Item struct {
+	ID int ` + "`json:\"id\"`" + `
+}
+
+type Problem struct {
+	Error string ` + "`json:\"error\"`" + `
+}
+
+func main() {
+	http.HandleFunc("GET /items/{id:[0-9]+}", SaveItem)
+	http.HandleFunc("PUT /items/{id:[0-9]+}", SaveItem)
+	http.HandleFunc("/any", SaveItem)
+}
+
+func SaveItem(w http.ResponseWriter, r *http.Request) {
+	var item Item
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Problem{Error: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(item)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, warnings := generate(t, dir)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "accepts any method") {
+		t.Errorf("warnings = %v, want only the route without a method", warnings)
+	}
+
+	item, ok := doc.Paths["/items/{id}"]
+	if !ok {
+		t.Fatalf("paths = %v, want the pattern removed from /items/{id:[0-9]+}", doc.Paths)
+	}
+	get, put := item["get"], item["put"]
+	if get == nil || put == nil {
+		t.Fatalf("operations = %v, want get and put", item)
+	}
+	if get.OperationID != "SaveItem" || put.OperationID != "SaveItemPut" {
+		t.Errorf("operationIds = %q, %q, want SaveItem and SaveItemPut", get.OperationID, put.OperationID)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" {
+		t.Errorf("parameters = %+v, want id", get.Parameters)
+	}
+
+	// The 400 from the error branch must not leak into the Encode after it
+	schemaOf := func(status string) string {
+		r := get.Responses[status]
+		if r == nil {
+			return ""
+		}
+		return r.Content["application/json"].Schema.Ref
+	}
+	if got := schemaOf("400"); got != "#/components/schemas/Problem" {
+		t.Errorf("400 schema = %q, want Problem", got)
+	}
+	if got := schemaOf("200"); got != "#/components/schemas/Item" {
+		t.Errorf("200 schema = %q, want Item", got)
+	}
+}
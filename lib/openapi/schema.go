@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// schemas converts Go types to schemas, collecting named structs as components
+type schemas struct {
+	components map[string]*Schema
+	// names maps each named struct to its component name
+	names map[*types.TypeName]string
+	docs  map[*types.TypeName]string
+}
+
+func newSchemas(docs map[*types.TypeName]string) *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[*types.TypeName]string),
+		docs:       docs,
+	}
+}
+
+// of returns the schema of values of type t as encoding/json writes them
+func (s *schemas) of(t types.Type) *Schema {
+	switch t := t.(type) {
+	case *types.Pointer:
+		schema := s.of(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			return &Schema{Ref: "#/components/schemas/" + s.component(obj, t)}
+		}
+		return s.of(t.Underlying())
+
+	case *types.Alias:
+		return s.of(types.Unalias(t))
+
+	case *types.Basic:
+		return basicSchema(t)
+
+	case *types.Slice:
+		if b, ok := t.Elem().(*types.Basic); ok && b.Kind() == types.Byte {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+
+	case *types.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+
+	case *types.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+
+	case *types.Struct:
+		return s.object(t)
+	}
+	// Interfaces and anything else can hold any JSON value
+	return &Schema{}
+}
+
+// component registers a named struct and returns its component name
+func (s *schemas) component(obj *types.TypeName, t *types.Named) string {
+	if name, ok := s.names[obj]; ok {
+		return name
+	}
+	name := obj.Name()
+	if _, taken := s.components[name]; taken {
+		name = obj.Pkg().Name() + "." + name
+	}
+	s.names[obj] = name
+	// Reserve the name before recursing so self-referencing types terminate
+	s.components[name] = &Schema{}
+	schema := s.object(t.Underlying().(*types.Struct))
+	schema.Description = s.docs[obj]
+	s.components[name] = schema
+	return name
+}
+
+// object builds an object schema from struct fields and their json tags
+func (s *schemas) object(st *types.Struct) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		name, omitempty, skip := jsonName(field, st.Tag(i))
+		if skip {
+			continue
+		}
+
+		// Untagged embedded structs are flattened into the parent
+		if field.Embedded() && name == "" {
+			if embedded := s.of(field.Type()); embedded.Ref != "" {
+				embedded = s.components[strings.TrimPrefix(embedded.Ref, "#/components/schemas/")]
+				for k, v := range embedded.Properties {
+					schema.Properties[k] = v
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name()
+		}
+
+		schema.Properties[name] = s.of(field.Type())
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// jsonName returns the JSON name of a field from its tag. skip is set for
+// unexported and "-" fields.
+func jsonName(field *types.Var, tag string) (name string, omitempty, skip bool) {
+	if !field.Exported() && !field.Embedded() {
+		return "", false, true
+	}
+	value, ok := reflect.StructTag(tag).Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if value == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(value, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+func basicSchema(t *types.Basic) *Schema {
+	info := t.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return &Schema{Type: "boolean"}
+	case info&types.IsInteger != 0:
+		switch t.Kind() {
+		case types.Int64, types.Uint64:
+			return &Schema{Type: "integer", Format: "int64"}
+		case types.Int32, types.Uint32:
+			return &Schema{Type: "integer", Format: "int32"}
+		}
+		return &Schema{Type: "integer"}
+	case info&types.IsFloat != 0:
+		if t.Kind() == types.Float32 {
+			return &Schema{Type: "number", Format: "float"}
+		}
+		return &Schema{Type: "number", Format: "double"}
+	case info&types.IsString != 0:
+		return &Schema{Type: "string"}
+	}
+	return &Schema{}
+}
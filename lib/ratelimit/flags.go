@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/toumakido/reAct/lib/envvar"
+)
+
+// RegisterFlags registers -rpm, -tpm and -max-concurrent on fs, defaulting from
+// RATE_LIMIT_RPM, RATE_LIMIT_TPM and RATE_LIMIT_MAX_CONCURRENT
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	cfg := &Config{}
+	fs.IntVar(&cfg.RequestsPerMinute, "rpm", envvar.Int("RATE_LIMIT_RPM", 0), "max model requests per minute, 0 for unlimited (env RATE_LIMIT_RPM)")
+	fs.IntVar(&cfg.TokensPerMinute, "tpm", envvar.Int("RATE_LIMIT_TPM", 0), "max model tokens per minute, 0 for unlimited (env RATE_LIMIT_TPM)")
+	fs.IntVar(&cfg.MaxConcurrent, "max-concurrent", envvar.Int("RATE_LIMIT_MAX_CONCURRENT", 0), "max concurrent model requests, 0 for unlimited (env RATE_LIMIT_MAX_CONCURRENT)")
+	return cfg
+}
+
+// Enabled reports whether any limit is set
+func (c Config) Enabled() bool {
+	return c.RequestsPerMinute > 0 || c.TokensPerMinute > 0 || c.MaxConcurrent > 0
+}
+
+// String formats the stats for the end-of-run summary
+func (s Stats) String() string {
+	avg := "0s"
+	if s.Waited > 0 {
+		avg = (s.TotalWait / time.Duration(s.Waited)).String()
+	}
+	return fmt.Sprintf("Requests: %d, Waited: %d, Total wait: %s, Avg wait: %s, Max wait: %s",
+		s.Requests, s.Waited, s.TotalWait, avg, s.MaxWait)
+}
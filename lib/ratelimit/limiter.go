@@ -0,0 +1,196 @@
+// Package ratelimit caps request rate, token rate and concurrency of model calls
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Config sets the limits. Zero values disable the corresponding limit.
+type Config struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	MaxConcurrent     int
+}
+
+// Stats reports how much callers have been delayed by the limiter
+type Stats struct {
+	Requests int
+	// Waited is the number of requests that had to wait
+	Waited    int
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// Limiter is a token-bucket limiter meant to be shared by every caller of a model client.
+// A nil *Limiter imposes no limits.
+type Limiter struct {
+	requests *bucket
+	tokens   *bucket
+	slots    chan struct{}
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New creates a Limiter with the given limits
+func New(cfg Config) *Limiter {
+	l := &Limiter{
+		requests: newBucket(cfg.RequestsPerMinute),
+		tokens:   newBucket(cfg.TokensPerMinute),
+	}
+	if cfg.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return l
+}
+
+// Reservation is an admitted request. Call Done once the request has finished.
+type Reservation struct {
+	limiter   *Limiter
+	estimated int
+	once      sync.Once
+}
+
+// Acquire blocks until a request estimated to use the given number of tokens may start,
+// or ctx is done
+func (l *Limiter) Acquire(ctx context.Context, estimatedTokens int) (*Reservation, error) {
+	if l == nil {
+		return &Reservation{}, nil
+	}
+
+	start := time.Now()
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+	if err := l.requests.take(ctx, 1); err != nil {
+		release()
+		return nil, err
+	}
+	if err := l.tokens.take(ctx, estimatedTokens); err != nil {
+		l.requests.give(1)
+		release()
+		return nil, err
+	}
+
+	l.record(time.Since(start))
+	return &Reservation{limiter: l, estimated: estimatedTokens}, nil
+}
+
+// Done releases the concurrency slot and corrects the token bucket by the
+// difference between the estimate and the tokens actually used
+func (r *Reservation) Done(actualTokens int) {
+	if r == nil || r.limiter == nil {
+		return
+	}
+	r.once.Do(func() {
+		l := r.limiter
+		l.tokens.give(r.estimated - actualTokens)
+		if l.slots != nil {
+			<-l.slots
+		}
+	})
+}
+
+// Stats returns the wait metrics collected so far
+func (l *Limiter) Stats() Stats {
+	if l == nil {
+		return Stats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+func (l *Limiter) record(wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.Requests++
+	// Ignore scheduling noise when deciding whether a request waited
+	if wait < time.Millisecond {
+		return
+	}
+	l.stats.Waited++
+	l.stats.TotalWait += wait
+	l.stats.MaxWait = max(l.stats.MaxWait, wait)
+}
+
+// bucket refills perMinute units evenly over a minute, up to perMinute
+type bucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	perSecond float64
+	available float64
+	last      time.Time
+}
+
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity:  float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		available: float64(perMinute),
+		last:      time.Now(),
+	}
+}
+
+// take waits until n units are available and consumes them.
+// Requests larger than the capacity wait for a full bucket.
+func (b *bucket) take(ctx context.Context, n int) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+	need := min(float64(n), b.capacity)
+
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.available >= need {
+			b.available -= float64(n)
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((need - b.available) / b.perSecond * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// give returns n units to the bucket; a negative n charges extra usage
+func (b *bucket) give(n int) {
+	if b == nil || n == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.available = min(b.available+float64(n), b.capacity)
+}
+
+func (b *bucket) refill() {
+	now := time.Now()
+	b.available = min(b.available+now.Sub(b.last).Seconds()*b.perSecond, b.capacity)
+	b.last = now
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketWaitsForRefill(t *testing.T) {
+	// 1000 tokens per second
+	l := New(Config{TokensPerMinute: 60_000})
+	ctx := context.Background()
+
+	r, err := l.Acquire(ctx, 60_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(60_000)
+
+	start := time.Now()
+	if _, err := l.Acquire(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("waited %s for 100 tokens at 1000/s, want about 100ms", elapsed)
+	}
+	if stats := l.Stats(); stats.Requests != 2 || stats.Waited != 1 || stats.MaxWait < 80*time.Millisecond {
+		t.Errorf("Stats = %+v, want 2 requests with one wait", stats)
+	}
+}
+
+func TestDoneCorrectsEstimate(t *testing.T) {
+	l := New(Config{TokensPerMinute: 60_000})
+	ctx := context.Background()
+
+	// Using fewer tokens than estimated gives the difference back
+	r, err := l.Acquire(ctx, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(100)
+	if got := l.tokens.available; got < 59_800 {
+		t.Errorf("available = %.0f after returning 900 unused tokens, want about 59900", got)
+	}
+
+	// Using more leaves the bucket in debt, which later callers wait for
+	r, err = l.Acquire(ctx, 59_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(61_000)
+	if got := l.tokens.available; got > -500 {
+		t.Errorf("available = %.0f after using 2000 more than estimated, want a debt", got)
+	}
+
+	// Done is idempotent
+	before := l.tokens.available
+	r.Done(0)
+	if got := l.tokens.available; got-before > 500 {
+		t.Errorf("available = %.0f after a second Done, want about %.0f", got, before)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	l := New(Config{MaxConcurrent: 1})
+	first, err := l.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Acquire error = %v, want it to wait for the slot until the deadline", err)
+	}
+
+	first.Done(0)
+	second, err := l.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A repeated Done must not release the slot now held by second
+	first.Done(0)
+	if got := len(l.slots); got != 1 {
+		t.Errorf("%d slots in use, want 1", got)
+	}
+	second.Done(0)
+	if got := len(l.slots); got != 0 {
+		t.Errorf("%d slots in use after Done, want 0", got)
+	}
+}
+
+func TestAcquireCancelReleasesSlot(t *testing.T) {
+	l := New(Config{RequestsPerMinute: 60, TokensPerMinute: 600, MaxConcurrent: 2})
+	r, err := l.Acquire(context.Background(), 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(600)
+
+	// The token bucket is empty, so this waits until cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := l.Acquire(ctx, 100); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire error = %v, want context.Canceled", err)
+	}
+	if got := len(l.slots); got != 0 {
+		t.Errorf("%d slots in use after a cancelled Acquire, want 0", got)
+	}
+	// The request it took is given back
+	if got := l.requests.available; got < 58.9 {
+		t.Errorf("requests available = %.2f, want the cancelled request returned", got)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	r, err := l.Acquire(context.Background(), 1_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Done(2_000_000)
+	if stats := l.Stats(); stats != (Stats{}) {
+		t.Errorf("Stats = %+v, want zero", stats)
+	}
+}
@@ -0,0 +1,401 @@
+package react
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/tools"
+	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
+)
+
+const defaultMaxIterations = 15
+
+// truncatedNotice is sent after a reply that hit the output token limit
+const truncatedNotice = "Your previous response was cut off because it exceeded the output token limit, so it was ignored. " +
+	"Respond again more concisely and finish with a complete action or Final Answer."
+
+// truncatedReply stands in for a truncated reply that had no text
+const truncatedReply = "[response truncated]"
+
+// ErrMaxIterations is returned when the loop ends without a Final Answer
+var ErrMaxIterations = errors.New("max iterations reached without final answer")
+
+// ToolMode selects how the model invokes tools
+type ToolMode int
+
+const (
+	// TextMode parses Action / Action Input lines out of the model output
+	TextMode ToolMode = iota
+	// NativeMode sends tool definitions to the model and consumes tool_use blocks
+	NativeMode
+)
+
+// String returns the name accepted by Set
+func (m ToolMode) String() string {
+	if m == NativeMode {
+		return "native"
+	}
+	return "text"
+}
+
+// Set parses "text" or "native", so a ToolMode can be used as a flag.Value
+func (m *ToolMode) Set(s string) error {
+	switch s {
+	case "text":
+		*m = TextMode
+	case "native":
+		*m = NativeMode
+	default:
+		return fmt.Errorf("unknown tool mode %q; use text or native", s)
+	}
+	return nil
+}
+
+// Config holds the loop settings of an agent
+type Config struct {
+	MaxIterations int
+	ToolMode      ToolMode
+	// Stream prints model output as it is generated when the backend supports it,
+	// and stops generation before a hallucinated Observation in TextMode
+	Stream bool
+	// Verbose prints observations in addition to model output
+	Verbose bool
+	// Output receives the progress log. Defaults to os.Stdout.
+	Output io.Writer
+	// MaxObservation truncates longer observations to this many bytes, 0 for no limit
+	MaxObservation int
+	// History compacts the conversation before each model call. Nil keeps it all.
+	History History
+}
+
+// DefaultConfig returns the default loop settings
+func DefaultConfig() Config {
+	return Config{
+		MaxIterations: defaultMaxIterations,
+		Stream:        true,
+	}
+}
+
+// Agent runs a ReAct loop against a model
+type Agent struct {
+	// Name is shown in the start banner
+	Name         string
+	SystemPrompt string
+	// Tools dispatches actions. In NativeMode its specs are sent to the model.
+	Tools  *tools.Registry
+	Client llm.LLM
+	Config Config
+}
+
+// Result is the outcome of a completed run
+type Result struct {
+	// Answer is the text following "Final Answer:"
+	Answer string
+	// Text is the full final model response
+	Text string
+	// Partial is set when the run stopped on a budget limit. Answer and Text then hold
+	// the last model response.
+	Partial          bool
+	Iterations       int
+	InputTokens      int
+	OutputTokens     int
+	CacheReadTokens  int
+	CacheWriteTokens int
+	Messages         []types.Message
+}
+
+// Run executes the ReAct loop until the model gives a Final Answer.
+// When a budget set with usage.WithBudget runs out, Run returns the partial Result
+// together with an error matching usage.ErrBudgetExceeded.
+func (a *Agent) Run(ctx context.Context, question string) (*Result, error) {
+	out := a.output()
+	maxIterations := a.Config.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultMaxIterations
+	}
+
+	messages := []types.Message{
+		{
+			Role:    "user",
+			Content: question,
+		},
+	}
+	res := &Result{}
+
+	fmt.Fprintf(out, "=== Starting %s ===\n", a.Name)
+	fmt.Fprintf(out, "Question: %s\n\n", question)
+
+	for i := 0; i < maxIterations; i++ {
+		if err := usage.CheckBudget(ctx); err != nil {
+			return a.abort(res, messages, err)
+		}
+
+		fmt.Fprintf(out, "--- Iteration %d ---\n", i+1)
+		res.Iterations = i + 1
+
+		next, done, err := a.step(ctx, messages, res)
+		if err != nil {
+			// A deadline cancels the call in flight, including a summarizer call, so
+			// report it as the budget it came from
+			if budgetErr := usage.CheckBudget(ctx); budgetErr != nil {
+				return a.abort(res, messages, budgetErr)
+			}
+			return nil, err
+		}
+		messages = next
+
+		if done {
+			fmt.Fprintln(out, "=== Agent Complete ===")
+			res.Messages = messages
+			return res, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w (%d)", ErrMaxIterations, maxIterations)
+}
+
+// abort ends a run that exceeded its budget, keeping the last model response as a partial answer
+func (a *Agent) abort(res *Result, messages []types.Message, err error) (*Result, error) {
+	fmt.Fprintf(a.output(), "[Budget] %v; stopping with a partial answer\n", err)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			res.Text = messageText(messages[i])
+			break
+		}
+	}
+	res.Answer = res.Text
+	if HasFinalAnswer(res.Text) {
+		res.Answer = ExtractFinalAnswer(res.Text)
+	}
+	res.Partial = true
+	res.Messages = messages
+	return res, err
+}
+
+// messageText returns the plain text of a message, joining text blocks
+func messageText(m types.Message) string {
+	if len(m.Blocks) == 0 {
+		return m.Content
+	}
+	var parts []string
+	for _, b := range m.Blocks {
+		if b.Type == types.BlockText {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// step compacts the history and runs one iteration in the configured tool mode
+func (a *Agent) step(ctx context.Context, messages []types.Message, res *Result) ([]types.Message, bool, error) {
+	if a.Config.History != nil {
+		compacted, err := a.Config.History.Compact(ctx, a.SystemPrompt, messages)
+		if err != nil {
+			return nil, false, err
+		}
+		messages = compacted
+	}
+	switch a.Config.ToolMode {
+	case NativeMode:
+		return a.stepNative(ctx, messages, res)
+	default:
+		return a.stepText(ctx, messages, res)
+	}
+}
+
+// stepText runs one iteration of the text-based loop
+func (a *Agent) stepText(ctx context.Context, messages []types.Message, res *Result) ([]types.Message, bool, error) {
+	result, err := a.complete(ctx, messages)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to invoke model: %w", err)
+	}
+	a.logUsage(ctx, result, res)
+
+	// A cut-off reply may hold a partial Action Input or Final Answer, so ask again instead of parsing it
+	if result.Truncated() {
+		return a.retryTruncated(messages, result.Text), false, nil
+	}
+
+	messages = append(messages, types.Message{
+		Role:    "assistant",
+		Content: result.Text,
+	})
+
+	if HasFinalAnswer(result.Text) {
+		res.Text = result.Text
+		res.Answer = ExtractFinalAnswer(result.Text)
+		return messages, true, nil
+	}
+
+	action, actionInput, found := ParseAction(result.Text)
+	if !found {
+		return messages, false, nil
+	}
+
+	observation, _ := a.execute(ctx, action, actionInput)
+
+	messages = append(messages, types.Message{
+		Role:    "user",
+		Content: fmt.Sprintf("Observation: %s", observation),
+	})
+	return messages, false, nil
+}
+
+// stepNative runs one iteration using the backend's native tool calling
+func (a *Agent) stepNative(ctx context.Context, messages []types.Message, res *Result) ([]types.Message, bool, error) {
+	caller, ok := a.Client.(llm.ToolCaller)
+	if !ok {
+		return nil, false, fmt.Errorf("model %T does not support native tool calling", a.Client)
+	}
+
+	result, err := caller.CompleteWithTools(ctx, a.SystemPrompt, messages, a.Tools.Specs())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to invoke model: %w", err)
+	}
+	fmt.Fprintln(a.output(), result.Text)
+	a.logUsage(ctx, result, res)
+
+	// Every tool_use must be answered by a tool_result, so a truncated reply
+	// keeps only its text and is retried
+	if result.Truncated() {
+		return a.retryTruncated(messages, result.Text), false, nil
+	}
+
+	messages = append(messages, types.Message{
+		Role:   "assistant",
+		Blocks: assistantBlocks(result),
+	})
+
+	// Without tool calls the reply is the answer
+	if len(result.ToolCalls) == 0 {
+		res.Text = result.Text
+		res.Answer = result.Text
+		if HasFinalAnswer(result.Text) {
+			res.Answer = ExtractFinalAnswer(result.Text)
+		}
+		return messages, true, nil
+	}
+
+	var results []types.ContentBlock
+	for _, call := range result.ToolCalls {
+		fmt.Fprintf(a.output(), "Action: %s\nAction Input: %s\n\n", call.Name, call.Input)
+		observation, failed := a.execute(ctx, call.Name, llm.InputText(call.Input))
+
+		results = append(results, types.ContentBlock{
+			Type:      types.BlockToolResult,
+			ToolUseID: call.ID,
+			Content:   observation,
+			IsError:   failed,
+		})
+	}
+	messages = append(messages, types.Message{
+		Role:   "user",
+		Blocks: results,
+	})
+	return messages, false, nil
+}
+
+// complete asks the model for the next text-mode turn, streaming it when possible.
+// The reply is printed either way.
+func (a *Agent) complete(ctx context.Context, messages []types.Message) (*llm.Result, error) {
+	streamer, ok := a.Client.(llm.Streamer)
+	if !a.Config.Stream || !ok {
+		result, err := a.Client.Complete(ctx, a.SystemPrompt, messages)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(a.output(), result.Text)
+		return result, nil
+	}
+
+	printer := &streamPrinter{out: a.output()}
+	result, err := streamer.CompleteStream(ctx, a.SystemPrompt, messages, printer.write)
+	if err != nil {
+		return nil, err
+	}
+	printer.finish()
+	fmt.Fprintln(a.output())
+	result.Text = printer.String()
+	return result, nil
+}
+
+// retryTruncated records the text of a reply that hit the output token limit and asks
+// the model to redo it. A reply cut inside its first tool_use has no text, and the API
+// rejects an empty assistant message, so a placeholder stands in for it.
+func (a *Agent) retryTruncated(messages []types.Message, text string) []types.Message {
+	fmt.Fprintf(a.output(), "[Truncated] Response hit the max_tokens limit; asking the model to retry\n\n")
+	if text == "" {
+		text = truncatedReply
+	}
+	return append(messages,
+		types.Message{Role: "assistant", Content: text},
+		types.Message{Role: "user", Content: truncatedNotice},
+	)
+}
+
+// assistantBlocks returns the content blocks to echo back for a native-mode reply.
+// Backends that report Content keep thinking blocks; otherwise blocks are rebuilt from Text and ToolCalls.
+func assistantBlocks(result *llm.Result) []types.ContentBlock {
+	if len(result.Content) > 0 {
+		return result.Content
+	}
+
+	var blocks []types.ContentBlock
+	if result.Text != "" {
+		blocks = append(blocks, types.ContentBlock{Type: types.BlockText, Text: result.Text})
+	}
+	for _, call := range result.ToolCalls {
+		blocks = append(blocks, types.ContentBlock{
+			Type:  types.BlockToolUse,
+			ID:    call.ID,
+			Name:  call.Name,
+			Input: call.Input,
+		})
+	}
+	return blocks
+}
+
+func (a *Agent) logUsage(ctx context.Context, result *llm.Result, res *Result) {
+	usage.FromContext(ctx).Record(a.Name, result)
+	res.InputTokens += result.InputTokens
+	res.OutputTokens += result.OutputTokens
+	res.CacheReadTokens += result.CacheReadInputTokens
+	res.CacheWriteTokens += result.CacheCreationInputTokens
+
+	out := a.output()
+	fmt.Fprintf(out, "\n[Token Usage] Input: %d, Output: %d, Total: %d",
+		result.InputTokens, result.OutputTokens, result.InputTokens+result.OutputTokens)
+	if result.CacheReadInputTokens > 0 || result.CacheCreationInputTokens > 0 {
+		fmt.Fprintf(out, ", Cache Read: %d, Cache Write: %d",
+			result.CacheReadInputTokens, result.CacheCreationInputTokens)
+	}
+	fmt.Fprint(out, "\n\n")
+}
+
+func (a *Agent) logObservation(observation string) {
+	if a.Config.Verbose {
+		fmt.Fprintf(a.output(), "Observation: %s\n\n", observation)
+	}
+}
+
+func (a *Agent) output() io.Writer {
+	if a.Config.Output == nil {
+		return os.Stdout
+	}
+	return a.Config.Output
+}
+
+// execute runs a tool and returns its observation, truncated to MaxObservation,
+// and whether the tool failed
+func (a *Agent) execute(ctx context.Context, action, actionInput string) (string, bool) {
+	observation, failed := a.Tools.Execute(ctx, action, actionInput)
+	observation = truncateObservation(observation, a.Config.MaxObservation)
+	a.logObservation(observation)
+	return observation, failed
+}
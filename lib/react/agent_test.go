@@ -0,0 +1,294 @@
+package react
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/llmtest"
+	"github.com/toumakido/reAct/lib/tools"
+	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
+)
+
+// lookupTool answers "value of <input>" and fails for "missing"
+func lookupTool() tools.Tool {
+	return tools.New("Lookup", "Looks up a value", llm.StringInputSchema("key", "Key to look up"),
+		func(ctx context.Context, input string) (string, error) {
+			if input == "missing" {
+				return "", errors.New("no such key")
+			}
+			return "value of " + input, nil
+		})
+}
+
+// newTestAgent returns an agent running on model with the Lookup tool and no log output
+func newTestAgent(model llm.LLM, configure ...func(*Config)) *Agent {
+	config := DefaultConfig()
+	config.Output = io.Discard
+	for _, f := range configure {
+		f(&config)
+	}
+	return &Agent{
+		Name:         "Test Agent",
+		SystemPrompt: "system prompt",
+		Tools:        tools.NewRegistry(lookupTool()),
+		Client:       model,
+		Config:       config,
+	}
+}
+
+func TestRunThreadsObservations(t *testing.T) {
+	for _, stream := range []bool{true, false} {
+		name := "complete"
+		if stream {
+			name = "stream"
+		}
+		t.Run(name, func(t *testing.T) {
+			model := llmtest.New(
+				llmtest.Action("I need the first value", "Lookup", "a"),
+				llmtest.Action("Now the second", "Lookup", "b"),
+				llmtest.FinalAnswer("I have both", "a and b"),
+			)
+			agent := newTestAgent(model, func(c *Config) { c.Stream = stream })
+
+			result, err := agent.Run(context.Background(), "What are a and b?")
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if result.Answer != "a and b" || result.Iterations != 3 {
+				t.Errorf("Answer, Iterations = %q, %d, want %q, 3", result.Answer, result.Iterations, "a and b")
+			}
+
+			calls := model.Calls()
+			if len(calls) != 3 {
+				t.Fatalf("got %d model calls, want 3", len(calls))
+			}
+			if calls[0].SystemPrompt != "system prompt" {
+				t.Errorf("SystemPrompt = %q", calls[0].SystemPrompt)
+			}
+			// Every call sees the whole conversation so far, observations included
+			last := calls[2].Messages
+			want := []struct{ role, content string }{
+				{"user", "What are a and b?"},
+				{"assistant", llmtest.Action("I need the first value", "Lookup", "a")},
+				{"user", "Observation: value of a"},
+				{"assistant", llmtest.Action("Now the second", "Lookup", "b")},
+				{"user", "Observation: value of b"},
+			}
+			if len(last) != len(want) {
+				t.Fatalf("last call has %d messages, want %d", len(last), len(want))
+			}
+			for i, w := range want {
+				if last[i].Role != w.role || last[i].Content != w.content {
+					t.Errorf("message %d = %s %q, want %s %q", i, last[i].Role, last[i].Content, w.role, w.content)
+				}
+			}
+			if len(result.Messages) != len(want)+1 {
+				t.Errorf("Result.Messages has %d messages, want %d", len(result.Messages), len(want)+1)
+			}
+		})
+	}
+}
+
+func TestRunErrorObservations(t *testing.T) {
+	model := llmtest.New(
+		llmtest.Action("Try a missing key", "Lookup", "missing"),
+		llmtest.Action("Try another tool", "Search", "x"),
+		llmtest.FinalAnswer("Neither worked", "unknown"),
+	)
+	agent := newTestAgent(model)
+
+	if _, err := agent.Run(context.Background(), "q"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	messages := model.Calls()[2].Messages
+	if got := messages[2].Content; got != "Observation: Error: Lookup: no such key" {
+		t.Errorf("tool error observation = %q", got)
+	}
+	if got := messages[4].Content; !strings.HasPrefix(got, "Observation: Error: Unknown action 'Search'. Available actions: Lookup") {
+		t.Errorf("unknown action observation = %q", got)
+	}
+}
+
+func TestRunWithoutActionContinues(t *testing.T) {
+	model := llmtest.New(
+		"Thought: let me think about it some more",
+		llmtest.FinalAnswer("done", "42"),
+	)
+	agent := newTestAgent(model)
+
+	result, err := agent.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "42" || result.Iterations != 2 {
+		t.Errorf("Answer, Iterations = %q, %d, want 42, 2", result.Answer, result.Iterations)
+	}
+}
+
+func TestRunMaxIterations(t *testing.T) {
+	model := llmtest.New(
+		llmtest.Action("one", "Lookup", "a"),
+		llmtest.Action("two", "Lookup", "b"),
+		llmtest.Action("three", "Lookup", "c"),
+	)
+	agent := newTestAgent(model, func(c *Config) { c.MaxIterations = 2 })
+
+	result, err := agent.Run(context.Background(), "q")
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("error = %v, want ErrMaxIterations", err)
+	}
+	if result != nil {
+		t.Errorf("result = %+v, want nil", result)
+	}
+	if got := len(model.Calls()); got != 2 {
+		t.Errorf("got %d model calls, want 2", got)
+	}
+}
+
+func TestRunModelError(t *testing.T) {
+	model := &llmtest.Model{}
+	model.Push(llmtest.Reply{Err: errors.New("service down")})
+	agent := newTestAgent(model)
+
+	_, err := agent.Run(context.Background(), "q")
+	if err == nil || !strings.Contains(err.Error(), "service down") {
+		t.Fatalf("error = %v, want the model error", err)
+	}
+}
+
+func TestRunTruncatedReplyIsRetried(t *testing.T) {
+	model := &llmtest.Model{}
+	model.Push(
+		llmtest.Reply{Text: "Thought: a long\nAction: Lookup\nAction Input: a very lo", StopReason: llm.StopMaxTokens},
+		llmtest.Reply{Text: llmtest.FinalAnswer("short now", "ok")},
+	)
+	agent := newTestAgent(model)
+
+	result, err := agent.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "ok" {
+		t.Errorf("Answer = %q, want ok", result.Answer)
+	}
+	messages := model.Calls()[1].Messages
+	if got := messages[len(messages)-1].Content; got != truncatedNotice {
+		t.Errorf("last message = %q, want the truncation notice instead of an observation", got)
+	}
+}
+
+func TestRunTokenBudgetReturnsPartial(t *testing.T) {
+	model := &llmtest.Model{}
+	model.Push(
+		llmtest.Reply{Text: llmtest.Action("Look it up", "Lookup", "a"), InputTokens: 80, OutputTokens: 30},
+		llmtest.Reply{Text: llmtest.FinalAnswer("done", "value of a")},
+	)
+	agent := newTestAgent(model)
+
+	ctx, cancel := usage.WithBudget(context.Background(), usage.Budget{MaxTokens: 100})
+	defer cancel()
+	result, err := agent.Run(ctx, "q")
+
+	var budgetErr *usage.BudgetError
+	if !errors.Is(err, usage.ErrBudgetExceeded) || !errors.As(err, &budgetErr) || budgetErr.Limit != "tokens" {
+		t.Fatalf("error = %v, want a tokens BudgetError", err)
+	}
+	if result == nil || !result.Partial {
+		t.Fatalf("result = %+v, want a partial result", result)
+	}
+	if result.Text != llmtest.Action("Look it up", "Lookup", "a") {
+		t.Errorf("Text = %q, want the last model response", result.Text)
+	}
+	if got := len(model.Calls()); got != 1 {
+		t.Errorf("got %d model calls, want 1", got)
+	}
+}
+
+// blockingLLM waits for its context to end
+type blockingLLM struct{}
+
+func (blockingLLM) Complete(ctx context.Context, systemPrompt string, messages []types.Message) (*llm.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunDeadlineDuringCompactReturnsPartial(t *testing.T) {
+	model := llmtest.New(
+		llmtest.Action("one", "Lookup", "a"),
+		llmtest.Action("two", "Lookup", "b"),
+		llmtest.FinalAnswer("done", "a and b"),
+	)
+	agent := newTestAgent(model, func(c *Config) {
+		c.History = &Window{MaxTokens: 1, KeepRecent: 1, Summarizer: blockingLLM{}}
+	})
+
+	ctx, cancel := usage.WithBudget(context.Background(), usage.Budget{Deadline: time.Now().Add(50 * time.Millisecond)})
+	defer cancel()
+	result, err := agent.Run(ctx, "q")
+
+	if !errors.Is(err, usage.ErrBudgetExceeded) {
+		t.Fatalf("error = %v, want ErrBudgetExceeded instead of the summarizer error", err)
+	}
+	if result == nil || !result.Partial {
+		t.Fatalf("result = %+v, want a partial result", result)
+	}
+	if result.Text != llmtest.Action("two", "Lookup", "b") {
+		t.Errorf("Text = %q, want the last model response", result.Text)
+	}
+}
+
+func TestRunNativeToolResults(t *testing.T) {
+	model := &llmtest.Model{}
+	model.Push(
+		llmtest.ToolUse("toolu_1", "Lookup", `{"key": "a"}`),
+		llmtest.ToolUse("toolu_2", "Lookup", `{"key": "missing"}`),
+		llmtest.Reply{Text: "Final Answer: only a"},
+	)
+	agent := newTestAgent(model, func(c *Config) { c.ToolMode = NativeMode })
+
+	result, err := agent.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "only a" {
+		t.Errorf("Answer = %q, want only a", result.Answer)
+	}
+
+	messages := model.Calls()[2].Messages
+	ok, failed := messages[2].Blocks[0], messages[4].Blocks[0]
+	if ok.ToolUseID != "toolu_1" || ok.Content != "value of a" || ok.IsError {
+		t.Errorf("tool_result = %+v, want value of a without IsError", ok)
+	}
+	if failed.ToolUseID != "toolu_2" || !failed.IsError {
+		t.Errorf("tool_result = %+v, want IsError for the failed call", failed)
+	}
+}
+
+func TestRunNativeTruncatedToolUseIsRetried(t *testing.T) {
+	model := &llmtest.Model{}
+	truncated := llmtest.ToolUse("toolu_1", "Lookup", `{"key": "a very lo`)
+	truncated.StopReason = llm.StopMaxTokens
+	model.Push(truncated, llmtest.Reply{Text: "Final Answer: ok"})
+	agent := newTestAgent(model, func(c *Config) { c.ToolMode = NativeMode })
+
+	result, err := agent.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "ok" {
+		t.Errorf("Answer = %q, want ok", result.Answer)
+	}
+	// The API rejects empty messages, so the text-less truncated reply needs a placeholder
+	for i, m := range model.Calls()[1].Messages {
+		if m.Content == "" && len(m.Blocks) == 0 {
+			t.Errorf("message %d (%s) has no content", i, m.Role)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package react
+
+import (
+	"flag"
+	"os"
+
+	"github.com/toumakido/reAct/lib/envvar"
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+const (
+	defaultContextWindow  = 100000
+	defaultMaxObservation = 30000
+)
+
+// HistoryFlags holds context window settings parsed from the command line
+type HistoryFlags struct {
+	ContextWindow  int
+	MaxObservation int
+	Summarize      bool
+	// SummarizeModel is a cheaper model on the agent's backend for the summaries;
+	// empty uses the agent's own model
+	SummarizeModel string
+}
+
+// RegisterFlags registers -context-window, -max-observation, -summarize-history and -summarize-model
+// on fs, defaulting from REACT_CONTEXT_WINDOW, REACT_MAX_OBSERVATION, REACT_SUMMARIZE_HISTORY and
+// REACT_SUMMARIZE_MODEL
+func RegisterFlags(fs *flag.FlagSet) *HistoryFlags {
+	f := &HistoryFlags{}
+	fs.IntVar(&f.ContextWindow, "context-window", envvar.Int("REACT_CONTEXT_WINDOW", defaultContextWindow), "Estimated tokens of history kept per model call, 0 for no limit (env REACT_CONTEXT_WINDOW)")
+	fs.IntVar(&f.MaxObservation, "max-observation", envvar.Int("REACT_MAX_OBSERVATION", defaultMaxObservation), "Truncate tool observations to this many bytes, 0 for no limit (env REACT_MAX_OBSERVATION)")
+	fs.BoolVar(&f.Summarize, "summarize-history", envvar.Bool("REACT_SUMMARIZE_HISTORY", false), "Summarize old turns with a model call instead of eliding observations (env REACT_SUMMARIZE_HISTORY)")
+	fs.StringVar(&f.SummarizeModel, "summarize-model", os.Getenv("REACT_SUMMARIZE_MODEL"), "Model on the same backend that writes the history summaries, empty for the agent's model (env REACT_SUMMARIZE_MODEL)")
+	return f
+}
+
+// RegisterToolModeFlag registers -tool-mode on fs, defaulting from REACT_TOOL_MODE
+func RegisterToolModeFlag(fs *flag.FlagSet) *ToolMode {
+	mode := TextMode
+	envvar.Set("REACT_TOOL_MODE", &mode)
+	fs.Var(&mode, "tool-mode", "How the model calls tools: text (Action lines) or native (tool_use blocks) (env REACT_TOOL_MODE)")
+	return &mode
+}
+
+// History returns the configured strategy, or nil when the window is disabled.
+// summarizer writes the summaries when -summarize-history is set; mains create it
+// for SummarizeModel when that is set.
+func (f *HistoryFlags) History(summarizer llm.LLM) History {
+	if f.ContextWindow <= 0 {
+		return nil
+	}
+	window := &Window{MaxTokens: f.ContextWindow}
+	if f.Summarize {
+		window.Summarizer = summarizer
+	}
+	return window
+}
@@ -0,0 +1,178 @@
+package react
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/types"
+	"github.com/toumakido/reAct/lib/usage"
+)
+
+const (
+	// elidedObservation replaces old observations dropped by Window
+	elidedObservation = "[elided to save context; run the tool again if you need it]"
+	// summaryHeader separates the question from the summary of earlier steps in the first message
+	summaryHeader     = "\n\n## Summary of earlier steps\n"
+	defaultKeepRecent = 4
+)
+
+const summarizePrompt = `You compress the working history of a tool-using agent.
+Summarize the transcript below so the agent can continue without it. Keep file paths, identifiers,
+facts learned from tool results and which tools were already tried. Drop raw file contents.
+Reply with the summary only, as plain prose or a short list.`
+
+// History compacts the conversation before each model call
+type History interface {
+	Compact(ctx context.Context, systemPrompt string, messages []types.Message) ([]types.Message, error)
+}
+
+// Window keeps the estimated size of the system prompt and conversation under MaxTokens.
+// Old observations are elided, or with a Summarizer, the turns before the most recent
+// ones are replaced by a summary written by that model. When that is not enough, the
+// recent observations are elided as well, up to the latest one.
+type Window struct {
+	MaxTokens int
+	// KeepRecent is the number of trailing messages never compacted. Defaults to 4.
+	KeepRecent int
+	// Summarizer is typically a cheaper model. Nil elides observations instead.
+	Summarizer llm.LLM
+}
+
+// Compact implements History
+func (w *Window) Compact(ctx context.Context, systemPrompt string, messages []types.Message) ([]types.Message, error) {
+	if w.MaxTokens <= 0 || EstimateTokens(systemPrompt, messages) <= w.MaxTokens {
+		return messages, nil
+	}
+
+	keep := w.KeepRecent
+	if keep <= 0 {
+		keep = defaultKeepRecent
+	}
+	// Cut before an assistant message so roles still alternate and every
+	// native tool_use stays next to its tool_result
+	cut := len(messages) - keep
+	for cut > 1 && messages[cut].Role != "assistant" {
+		cut--
+	}
+
+	if w.Summarizer != nil && cut > 1 {
+		summarized, err := w.summarize(ctx, messages, cut)
+		if err != nil {
+			return nil, err
+		}
+		messages, cut = summarized, 1
+		if EstimateTokens(systemPrompt, messages) <= w.MaxTokens {
+			return messages, nil
+		}
+	}
+
+	// Move the cut toward the end until the history fits or only the latest step is left
+	compacted := messages
+	for ; cut < len(messages); cut++ {
+		if cut <= 1 || messages[cut].Role != "assistant" {
+			continue
+		}
+		compacted = elide(messages, cut)
+		if EstimateTokens(systemPrompt, compacted) <= w.MaxTokens {
+			break
+		}
+	}
+	return compacted, nil
+}
+
+// summarize folds messages[:cut] into the first message
+func (w *Window) summarize(ctx context.Context, messages []types.Message, cut int) ([]types.Message, error) {
+	result, err := w.Summarizer.Complete(ctx, summarizePrompt, []types.Message{
+		{Role: "user", Content: transcript(messages[:cut])},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize history: %w", err)
+	}
+	usage.FromContext(ctx).Record("History Summarizer", result)
+
+	question, _, _ := strings.Cut(messageText(messages[0]), summaryHeader)
+	compacted := []types.Message{{Role: "user", Content: question + summaryHeader + strings.TrimSpace(result.Text)}}
+	return append(compacted, messages[cut:]...), nil
+}
+
+// elide returns a copy of messages with the observations before cut replaced by a marker
+func elide(messages []types.Message, cut int) []types.Message {
+	compacted := make([]types.Message, len(messages))
+	copy(compacted, messages)
+	for i := 1; i < cut; i++ {
+		m := compacted[i]
+		if m.Role != "user" {
+			continue
+		}
+		if strings.HasPrefix(m.Content, "Observation:") {
+			m.Content = "Observation: " + elidedObservation
+		}
+		if len(m.Blocks) > 0 {
+			blocks := make([]types.ContentBlock, len(m.Blocks))
+			copy(blocks, m.Blocks)
+			for j := range blocks {
+				if blocks[j].Type == types.BlockToolResult {
+					blocks[j].Content = elidedObservation
+				}
+			}
+			m.Blocks = blocks
+		}
+		compacted[i] = m
+	}
+	return compacted
+}
+
+// transcript renders messages as plain text for the summarizer.
+// Tool results are labelled "Tool result:" so a stop sequence on "Observation:" doesn't cut the reply.
+func transcript(messages []types.Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		if len(m.Blocks) == 0 {
+			text := m.Content
+			if rest, ok := strings.CutPrefix(text, "Observation:"); ok {
+				text = "Tool result:" + rest
+			}
+			fmt.Fprintf(&b, "[%s]\n%s\n\n", m.Role, text)
+			continue
+		}
+		for _, block := range m.Blocks {
+			switch block.Type {
+			case types.BlockText:
+				fmt.Fprintf(&b, "[%s]\n%s\n\n", m.Role, block.Text)
+			case types.BlockToolUse:
+				fmt.Fprintf(&b, "[%s]\nAction: %s %s\n\n", m.Role, block.Name, block.Input)
+			case types.BlockToolResult:
+				fmt.Fprintf(&b, "[%s]\nTool result: %s\n\n", m.Role, block.Content)
+			}
+		}
+	}
+	return b.String()
+}
+
+// EstimateTokens approximates the token count of a request at four bytes per token
+func EstimateTokens(systemPrompt string, messages []types.Message) int {
+	n := len(systemPrompt)
+	for _, m := range messages {
+		n += len(m.Content)
+		for _, block := range m.Blocks {
+			n += len(block.Text) + len(block.Input) + len(block.Content) + len(block.Thinking)
+		}
+	}
+	return n / 4
+}
+
+// truncateObservation cuts observations longer than max bytes, leaving a marker
+func truncateObservation(observation string, max int) string {
+	if max <= 0 || len(observation) <= max {
+		return observation
+	}
+	// Back up to a rune boundary
+	cut := max
+	for cut > 0 && !utf8.RuneStart(observation[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n[truncated: showing %d of %d bytes]", observation[:cut], cut, len(observation))
+}
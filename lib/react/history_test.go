@@ -0,0 +1,171 @@
+package react
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llmtest"
+	"github.com/toumakido/reAct/lib/types"
+)
+
+// conversation returns a question followed by n text-mode steps with long observations
+func conversation(n int) []types.Message {
+	messages := []types.Message{{Role: "user", Content: "q"}}
+	for i := 0; i < n; i++ {
+		messages = append(messages,
+			types.Message{Role: "assistant", Content: llmtest.Action("step", "Lookup", "a")},
+			types.Message{Role: "user", Content: "Observation: " + strings.Repeat("x", 400)},
+		)
+	}
+	return messages
+}
+
+func TestWindowUnderLimit(t *testing.T) {
+	messages := conversation(3)
+	w := &Window{MaxTokens: EstimateTokens("system", messages)}
+
+	got, err := w.Compact(context.Background(), "system", messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	for i := range messages {
+		if got[i].Content != messages[i].Content {
+			t.Errorf("message %d changed under the limit", i)
+		}
+	}
+}
+
+func TestWindowElidesOldObservations(t *testing.T) {
+	messages := conversation(4)
+	// Eliding two of the four observations brings the history under the limit
+	w := &Window{MaxTokens: 300, KeepRecent: 3}
+
+	got, err := w.Compact(context.Background(), "", messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if len(got) != len(messages) {
+		t.Fatalf("got %d messages, want %d", len(got), len(messages))
+	}
+	// KeepRecent 3 would cut at an observation, so the cut moves back to the assistant message at 5
+	for i, m := range got {
+		elided := m.Content == "Observation: "+elidedObservation
+		if want := i == 2 || i == 4; elided != want {
+			t.Errorf("message %d elided = %v, want %v", i, elided, want)
+		}
+	}
+	if messages[2].Content == got[2].Content {
+		t.Errorf("Compact modified its input")
+	}
+}
+
+func TestWindowElidesUntilItFits(t *testing.T) {
+	messages := conversation(4)
+	w := &Window{MaxTokens: 210, KeepRecent: 3}
+
+	got, err := w.Compact(context.Background(), "", messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	// Only the latest observation is left even though KeepRecent covers two
+	for i, m := range got {
+		elided := m.Content == "Observation: "+elidedObservation
+		if want := i == 2 || i == 4 || i == 6; elided != want {
+			t.Errorf("message %d elided = %v, want %v", i, elided, want)
+		}
+	}
+	if n := EstimateTokens("", got); n > w.MaxTokens {
+		t.Errorf("compacted history has %d tokens, over the limit of %d", n, w.MaxTokens)
+	}
+}
+
+func TestWindowCountsSystemPrompt(t *testing.T) {
+	messages := conversation(4)
+	size := EstimateTokens("", messages)
+	w := &Window{MaxTokens: size, KeepRecent: 3}
+
+	if got, _ := w.Compact(context.Background(), "", messages); got[2].Content != messages[2].Content {
+		t.Errorf("history at the limit was compacted")
+	}
+	got, err := w.Compact(context.Background(), strings.Repeat("s", 400), messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if got[2].Content == messages[2].Content {
+		t.Errorf("history was not compacted although the system prompt puts it over the limit")
+	}
+}
+
+func TestWindowElidesToolResults(t *testing.T) {
+	result := types.Message{Role: "user", Blocks: []types.ContentBlock{
+		{Type: types.BlockToolResult, ToolUseID: "toolu_1", Content: strings.Repeat("x", 400)},
+	}}
+	use := types.Message{Role: "assistant", Blocks: []types.ContentBlock{
+		{Type: types.BlockToolUse, ID: "toolu_1", Name: "Lookup", Input: []byte(`{"key":"a"}`)},
+	}}
+	messages := []types.Message{{Role: "user", Content: "q"}, use, result, use, result}
+	w := &Window{MaxTokens: 10, KeepRecent: 2}
+
+	got, err := w.Compact(context.Background(), "", messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if c := got[2].Blocks[0].Content; c != elidedObservation {
+		t.Errorf("old tool_result = %q, want it elided", c)
+	}
+	if c := got[4].Blocks[0].Content; c == elidedObservation {
+		t.Errorf("recent tool_result was elided")
+	}
+	if messages[2].Blocks[0].Content == elidedObservation {
+		t.Errorf("Compact modified the blocks of its input")
+	}
+}
+
+func TestWindowSummarizes(t *testing.T) {
+	summarizer := llmtest.New("Looked up a three times.")
+	messages := conversation(4)
+	w := &Window{MaxTokens: 100, KeepRecent: 2, Summarizer: summarizer}
+
+	got, err := w.Compact(context.Background(), "", messages)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want the summary and the last step", len(got))
+	}
+	if want := "q" + summaryHeader + "Looked up a three times."; got[0].Content != want {
+		t.Errorf("first message = %q, want %q", got[0].Content, want)
+	}
+	if got[1].Role != "assistant" {
+		t.Errorf("the message after the summary has role %q, want assistant", got[1].Role)
+	}
+
+	sent := summarizer.Calls()[0].Messages[0].Content
+	if strings.Contains(sent, "Observation:") || !strings.Contains(sent, "Tool result:") {
+		t.Errorf("transcript should label observations as tool results: %q", sent)
+	}
+
+	// A second summary replaces the first instead of stacking under it
+	summarizer.Push(llmtest.Reply{Text: "Still looking."})
+	again, err := w.Compact(context.Background(), "", append(got, conversation(2)[1:]...))
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if want := "q" + summaryHeader + "Still looking."; again[0].Content != want {
+		t.Errorf("first message = %q, want %q", again[0].Content, want)
+	}
+}
+
+func TestTruncateObservation(t *testing.T) {
+	if got := truncateObservation("short", 10); got != "short" {
+		t.Errorf("truncateObservation() = %q, want it unchanged", got)
+	}
+	if got := truncateObservation("short", 0); got != "short" {
+		t.Errorf("truncateObservation() with no limit = %q, want it unchanged", got)
+	}
+	// "é" is two bytes, so a cut at 2 backs up to the rune boundary at 1
+	if got, want := truncateObservation("aé bc", 2), "a\n[truncated: showing 1 of 6 bytes]"; got != want {
+		t.Errorf("truncateObservation() = %q, want %q", got, want)
+	}
+}
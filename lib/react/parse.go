@@ -0,0 +1,57 @@
+package react
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	actionRegex      = regexp.MustCompile(`(?i)Action:\s*(\w+)`)
+	actionInputRegex = regexp.MustCompile(`(?i)Action Input:\s*(.+?)(?:\n|$)`)
+)
+
+// ParseAction extracts the Action and Action Input lines from a model response.
+// Action Input is optional; found is false only when no Action line exists.
+func ParseAction(response string) (action string, actionInput string, found bool) {
+	actionMatch := actionRegex.FindStringSubmatch(response)
+	if len(actionMatch) < 2 {
+		return "", "", false
+	}
+	action = strings.TrimSpace(actionMatch[1])
+
+	actionInputMatch := actionInputRegex.FindStringSubmatch(response)
+	if len(actionInputMatch) >= 2 {
+		actionInput = strings.TrimSpace(actionInputMatch[1])
+	}
+
+	return action, actionInput, true
+}
+
+// HasFinalAnswer reports whether the response contains a Final Answer
+func HasFinalAnswer(response string) bool {
+	return strings.Contains(response, "Final Answer:")
+}
+
+// ExtractFinalAnswer returns the text following "Final Answer:" in the response
+func ExtractFinalAnswer(response string) string {
+	lines := strings.Split(response, "\n")
+	inFinalAnswer := false
+	var answer []string
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Final Answer:") {
+			inFinalAnswer = true
+			// Include the content after "Final Answer:" on the same line
+			content := strings.TrimSpace(strings.TrimPrefix(line, "Final Answer:"))
+			if content != "" {
+				answer = append(answer, content)
+			}
+			continue
+		}
+		if inFinalAnswer {
+			answer = append(answer, line)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(answer, "\n"))
+}
@@ -0,0 +1,106 @@
+package react
+
+import "testing"
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantAction string
+		wantInput  string
+		wantFound  bool
+	}{
+		{
+			name:       "thought action input",
+			response:   "Thought: I should read the file\nAction: ReadFile\nAction Input: start.txt",
+			wantAction: "ReadFile",
+			wantInput:  "start.txt",
+			wantFound:  true,
+		},
+		{
+			name:       "input followed by more lines",
+			response:   "Action: ReadFile\nAction Input: cmd/api/main.go\nThought: then look at handlers",
+			wantAction: "ReadFile",
+			wantInput:  "cmd/api/main.go",
+			wantFound:  true,
+		},
+		{
+			name:       "case insensitive with extra spaces",
+			response:   "action:   ListFiles\naction input:   none  ",
+			wantAction: "ListFiles",
+			wantInput:  "none",
+			wantFound:  true,
+		},
+		{
+			name:       "missing input",
+			response:   "Thought: list first\nAction: ListFiles",
+			wantAction: "ListFiles",
+			wantFound:  true,
+		},
+		{
+			name:       "json input",
+			response:   `Action: QuerySymbol` + "\n" + `Action Input: {"query": "callers", "symbol": "UpdateStock"}`,
+			wantAction: "QuerySymbol",
+			wantInput:  `{"query": "callers", "symbol": "UpdateStock"}`,
+			wantFound:  true,
+		},
+		{
+			name:     "no action",
+			response: "Thought: I know the answer\nFinal Answer: 42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, input, found := ParseAction(tt.response)
+			if action != tt.wantAction || input != tt.wantInput || found != tt.wantFound {
+				t.Errorf("ParseAction() = %q, %q, %v, want %q, %q, %v",
+					action, input, found, tt.wantAction, tt.wantInput, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestExtractFinalAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "same line",
+			response: "Thought: done\nFinal Answer: The key is in room 42.",
+			want:     "The key is in room 42.",
+		},
+		{
+			name:     "multiple lines",
+			response: "Thought: done\nFinal Answer: The endpoints are:\n- GET /api/users\n- POST /api/users\n",
+			want:     "The endpoints are:\n- GET /api/users\n- POST /api/users",
+		},
+		{
+			name:     "answer starts on the next line",
+			response: "Final Answer:\n\nThree parts.",
+			want:     "Three parts.",
+		},
+		{
+			name:     "no final answer",
+			response: "Thought: keep going\nAction: ReadFile",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractFinalAnswer(tt.response); got != tt.want {
+				t.Errorf("ExtractFinalAnswer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFinalAnswer(t *testing.T) {
+	if !HasFinalAnswer("Thought: done\nFinal Answer: yes") {
+		t.Errorf("HasFinalAnswer() = false for a response with a Final Answer")
+	}
+	if HasFinalAnswer("Thought: not yet\nAction: ReadFile\nAction Input: a.txt") {
+		t.Errorf("HasFinalAnswer() = true for an action")
+	}
+}
@@ -0,0 +1,65 @@
+package react
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// observationMarker starts an Observation the model is not supposed to write itself
+const observationMarker = "\nObservation:"
+
+// streamPrinter prints streamed text live and cuts it before a hallucinated Observation
+type streamPrinter struct {
+	out     io.Writer
+	text    strings.Builder
+	printed int
+	cut     bool
+}
+
+// write handles one delta and reports whether generation should continue
+func (p *streamPrinter) write(delta string) bool {
+	p.text.WriteString(delta)
+	full := p.text.String()
+
+	if i := strings.Index(full, observationMarker); i >= 0 {
+		p.flush(full[:i])
+		p.cut = true
+		return false
+	}
+
+	// Hold back a trailing partial marker until we know whether it completes
+	p.flush(full[:len(full)-partialSuffix(full, observationMarker)])
+	return true
+}
+
+// finish prints a partial marker still held back when the stream ended
+func (p *streamPrinter) finish() {
+	p.flush(p.String())
+}
+
+// String returns the text kept so far
+func (p *streamPrinter) String() string {
+	full := p.text.String()
+	if p.cut {
+		full = full[:strings.Index(full, observationMarker)]
+	}
+	return full
+}
+
+func (p *streamPrinter) flush(upTo string) {
+	if len(upTo) > p.printed {
+		fmt.Fprint(p.out, upTo[p.printed:])
+		p.printed = len(upTo)
+	}
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a proper prefix of marker
+func partialSuffix(s, marker string) int {
+	for n := len(marker) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, marker[:n]) {
+			return n
+		}
+	}
+	return 0
+}
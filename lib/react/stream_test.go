@@ -0,0 +1,141 @@
+package react
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llmtest"
+)
+
+func TestPartialSuffix(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"Action Input: a.txt", 0},
+		{"Action Input: a.txt\n", 1},
+		{"Action Input: a.txt\nObs", 4},
+		{"Action Input: a.txt\nObservation", 12},
+		// A complete marker is not a proper prefix, so it is left to the caller
+		{"Action Input: a.txt\nObservation:", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := partialSuffix(tt.s, observationMarker); got != tt.want {
+			t.Errorf("partialSuffix(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStreamPrinter(t *testing.T) {
+	tests := []struct {
+		name        string
+		deltas      []string
+		wantPrinted string
+		wantText    string
+		wantCut     bool
+	}{
+		{
+			name:        "no observation",
+			deltas:      []string{"Thought: read\n", "Action: ReadFile\n", "Action Input: a.txt"},
+			wantPrinted: "Thought: read\nAction: ReadFile\nAction Input: a.txt",
+			wantText:    "Thought: read\nAction: ReadFile\nAction Input: a.txt",
+		},
+		{
+			name:        "marker split across deltas",
+			deltas:      []string{"Action: ReadFile\nAction Input: a.txt\nObs", "ervation: made up", " content"},
+			wantPrinted: "Action: ReadFile\nAction Input: a.txt",
+			wantText:    "Action: ReadFile\nAction Input: a.txt",
+			wantCut:     true,
+		},
+		{
+			name:        "held back prefix that does not complete",
+			deltas:      []string{"Thought: a\n", "Obstacles remain"},
+			wantPrinted: "Thought: a\nObstacles remain",
+			wantText:    "Thought: a\nObstacles remain",
+		},
+		{
+			name:        "trailing partial marker is printed when the stream ends",
+			deltas:      []string{"Action Input: a.txt\nObserv"},
+			wantPrinted: "Action Input: a.txt\nObserv",
+			wantText:    "Action Input: a.txt\nObserv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			p := &streamPrinter{out: &out}
+			cut := false
+			for _, delta := range tt.deltas {
+				if !p.write(delta) {
+					cut = true
+					break
+				}
+			}
+			p.finish()
+			if cut != tt.wantCut {
+				t.Errorf("cut = %v, want %v", cut, tt.wantCut)
+			}
+			if out.String() != tt.wantPrinted {
+				t.Errorf("printed %q, want %q", out.String(), tt.wantPrinted)
+			}
+			if p.String() != tt.wantText {
+				t.Errorf("String() = %q, want %q", p.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestStreamPrinterHoldsBackPartialMarker(t *testing.T) {
+	var out strings.Builder
+	p := &streamPrinter{out: &out}
+	p.write("Action Input: a.txt\nObs")
+	if out.String() != "Action Input: a.txt" {
+		t.Errorf("printed %q while the marker may still complete", out.String())
+	}
+	p.write("tacles remain")
+	if out.String() != "Action Input: a.txt\nObstacles remain" {
+		t.Errorf("printed %q, want the held back text once it cannot be a marker", out.String())
+	}
+}
+
+func TestRunCutsHallucinatedObservation(t *testing.T) {
+	model := llmtest.New(
+		llmtest.Action("Look it up", "Lookup", "a")+"\nObservation: invented value\nThought: done\nFinal Answer: wrong",
+		llmtest.FinalAnswer("Now I know", "value of a"),
+	)
+	agent := newTestAgent(model)
+
+	result, err := agent.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "value of a" {
+		t.Errorf("Answer = %q, want the answer after the real observation", result.Answer)
+	}
+
+	messages := model.Calls()[1].Messages
+	if got := messages[1].Content; got != llmtest.Action("Look it up", "Lookup", "a") {
+		t.Errorf("assistant message = %q, want it cut before the invented Observation", got)
+	}
+	if got := messages[2].Content; got != "Observation: value of a" {
+		t.Errorf("observation = %q, want the tool result", got)
+	}
+}
+
+func TestRunPrintsHeldBackTail(t *testing.T) {
+	reply := llmtest.FinalAnswer("done", "see the notes under\nObs")
+	var out strings.Builder
+	agent := newTestAgent(llmtest.New(reply), func(c *Config) {
+		c.Stream = true
+		c.Output = &out
+	})
+
+	if _, err := agent.Run(context.Background(), "q"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(out.String(), reply+"\n") {
+		t.Errorf("log %q does not contain the whole reply", out.String())
+	}
+}
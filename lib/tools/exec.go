@@ -0,0 +1,263 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// ExecConfig limits the commands run by the Exec tool
+type ExecConfig struct {
+	// Commands lists the allowed commands as argument prefixes, e.g. {"go", "test"}
+	Commands [][]string
+	// Flags lists the allowed flags, mapped to whether they take a value
+	Flags   map[string]bool
+	Timeout time.Duration
+	// MaxOutput caps the combined output returned to the model in bytes
+	MaxOutput int
+}
+
+// DefaultExecConfig allows go build, go vet and go test with flags that neither
+// write files nor run other programs. Exec discards the output of go build.
+func DefaultExecConfig() ExecConfig {
+	return ExecConfig{
+		Commands: [][]string{
+			{"go", "build"},
+			{"go", "vet"},
+			{"go", "test"},
+		},
+		Flags: map[string]bool{
+			"-v":         false,
+			"-run":       true,
+			"-skip":      true,
+			"-count":     true,
+			"-short":     false,
+			"-failfast":  false,
+			"-cover":     false,
+			"-timeout":   true,
+			"-json":      false,
+			"-bench":     true,
+			"-benchtime": true,
+			"-list":      true,
+			"-tags":      true,
+		},
+		Timeout:   2 * time.Minute,
+		MaxOutput: DefaultMaxBytes,
+	}
+}
+
+// Exec runs an allowed command in the root of fsys and returns its exit code and
+// combined output. The command gets a scrubbed environment with GOPROXY=off and,
+// on Linux, an empty network namespace. It is not a filesystem sandbox: go test runs
+// the code of the data directory with the full filesystem access of the user, and
+// the empty HOME does not protect absolute paths.
+func (c ExecConfig) Exec(ctx context.Context, fsys *FS, commandLine string) (exitCode int, output string, err error) {
+	args := strings.Fields(commandLine)
+	if err := c.check(args); err != nil {
+		return 0, "", err
+	}
+	// go build would leave binaries for main packages in the data directory
+	if len(args) >= 2 && args[0] == "go" && args[1] == "build" {
+		args = append([]string{"go", "build", "-o", os.DevNull}, args[2:]...)
+	}
+	dir, ok := fsys.Dir()
+	if !ok {
+		return 0, "", errors.New("Exec needs a data directory on disk")
+	}
+	env, err := execEnv()
+	if err != nil {
+		return 0, "", err
+	}
+	home, err := os.MkdirTemp("", "react-exec-home")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.RemoveAll(home)
+	env = append(slices.Clip(env), "HOME="+home)
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	out := &cappedBuffer{max: c.MaxOutput}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = 5 * time.Second
+	isolate(cmd)
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return -1, out.String() + fmt.Sprintf("\n[timed out after %s]", c.Timeout), nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode(), out.String(), nil
+	case err != nil:
+		if isolationUnavailable(err) {
+			return 0, "", fmt.Errorf("Exec needs unprivileged user namespaces, which this system does not allow (common in containers; check kernel.unprivileged_userns_clone or user.max_user_namespaces): %w", err)
+		}
+		return 0, "", fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	return 0, out.String(), nil
+}
+
+// check rejects commands outside the allowlist and arguments that could leave the root
+func (c ExecConfig) check(args []string) error {
+	allowed := false
+	var prefix []string
+	for _, command := range c.Commands {
+		if len(args) >= len(command) && equal(args[:len(command)], command) {
+			allowed, prefix = true, command
+			break
+		}
+	}
+	if !allowed {
+		names := make([]string, len(c.Commands))
+		for i, command := range c.Commands {
+			names[i] = strings.Join(command, " ")
+		}
+		return fmt.Errorf("command not allowed; use one of: %s", strings.Join(names, ", "))
+	}
+
+	rest := args[len(prefix):]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(strings.Replace(arg, "--", "-", 1), "=")
+			takesValue, ok := c.Flags[name]
+			if !ok {
+				return fmt.Errorf("flag %s not allowed", name)
+			}
+			if takesValue && !hasValue {
+				i++
+			}
+			continue
+		}
+		// Package patterns must stay inside the root
+		if path.IsAbs(arg) || !isLocalPattern(arg) {
+			return fmt.Errorf("argument %s must be a package path inside the data directory, e.g. ./...", arg)
+		}
+	}
+	return nil
+}
+
+func isLocalPattern(arg string) bool {
+	for _, part := range strings.Split(arg, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	goEnvOnce sync.Once
+	goEnv     []string
+	goEnvErr  error
+)
+
+// execEnv returns the environment for commands: the go tool's cache locations and
+// fixed offline settings. Credentials and everything else in the parent environment
+// are left out; Exec adds a HOME pointing at an empty directory. GOFLAGS is unset so
+// the go command uses vendor/ when present and never rewrites go.mod or go.sum.
+func execEnv() ([]string, error) {
+	goEnvOnce.Do(func() {
+		out, err := exec.Command("go", "env", "GOROOT", "GOPATH", "GOMODCACHE", "GOCACHE").Output()
+		if err != nil {
+			goEnvErr = fmt.Errorf("failed to read go env: %w", err)
+			return
+		}
+		values := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(values) != 4 {
+			goEnvErr = errors.New("unexpected go env output")
+			return
+		}
+		goEnv = []string{
+			"PATH=" + os.Getenv("PATH"),
+			"GOROOT=" + values[0],
+			"GOPATH=" + values[1],
+			"GOMODCACHE=" + values[2],
+			"GOCACHE=" + values[3],
+			"GOPROXY=off",
+			"GOWORK=off",
+			"GOTOOLCHAIN=local",
+			"GOTELEMETRY=off",
+			"CGO_ENABLED=0",
+		}
+	})
+	return goEnv, goEnvErr
+}
+
+// cappedBuffer keeps the first max bytes written and counts the rest
+type cappedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	max   int
+	total int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total += len(p)
+	if room := b.max - b.buf.Len(); b.max <= 0 || room >= len(p) {
+		b.buf.Write(p)
+	} else if room > 0 {
+		b.buf.Write(p[:room])
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.max > 0 && b.total > b.max {
+		return fmt.Sprintf("%s\n[output truncated: showing %d of %d bytes]", b.buf.String(), b.buf.Len(), b.total)
+	}
+	return b.buf.String()
+}
+
+// ExecTool returns the Exec tool, which runs the commands allowed by config in fsys
+func ExecTool(fsys *FS, config ExecConfig) Tool {
+	names := make([]string, len(config.Commands))
+	for i, command := range config.Commands {
+		names[i] = strings.Join(command, " ")
+	}
+	return New("Exec",
+		fmt.Sprintf("Runs a command in the data directory%s and returns its exit code and output. Allowed commands: %s", isolationNote, strings.Join(names, ", ")),
+		llm.StringInputSchema("command", "Command line, e.g. go test ./..."),
+		func(ctx context.Context, input string) (string, error) {
+			exitCode, output, err := config.Exec(ctx, fsys, input)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Exit code: %d\n%s", exitCode, output), nil
+		})
+}
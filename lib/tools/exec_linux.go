@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolationNote tells the model what isolate guarantees
+const isolationNote = " without network access"
+
+// isolate runs cmd in new user and network namespaces, so it has only a loopback
+// interface, and in its own process group so a timeout kills its children too
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		Setpgid:     true,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// isolationUnavailable reports whether starting cmd failed because the kernel refused
+// to create the namespaces, as it does where unprivileged user namespaces are disabled
+func isolationUnavailable(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOSPC)
+}
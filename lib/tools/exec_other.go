@@ -0,0 +1,14 @@
+//go:build !linux
+
+package tools
+
+import "os/exec"
+
+// isolationNote is empty because commands keep network access outside Linux
+const isolationNote = ""
+
+// isolate is a no-op outside Linux; the scrubbed environment with GOPROXY=off is the
+// only network restriction there
+func isolate(cmd *exec.Cmd) {}
+
+func isolationUnavailable(err error) bool { return false }
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecCheck(t *testing.T) {
+	config := DefaultExecConfig()
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{"go test ./...", false},
+		{"go build", false},
+		{"go vet ./internal/...", false},
+		{"go test -run TestAdd ./calc", false},
+		{"go test -run=TestAdd -count=1 -v ./...", false},
+		{"go test --run=TestAdd ./...", false},
+		// A value flag consumes the next argument, even one that looks like a path
+		{"go test -run ../.. ./...", false},
+		{"go test -exec /bin/sh ./...", true},
+		{"go test -toolexec=strace ./...", true},
+		{"go build -o /tmp/x .", true},
+		{"go build -C /etc .", true},
+		{"go test -modfile=/tmp/go.mod ./...", true},
+		{"go test ../..", true},
+		{"go test ./a/../../b", true},
+		{"go test /etc", true},
+		{"go run .", true},
+		{"go", true},
+		{"sh -c 'go test'", true},
+		{"rm -rf .", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		err := config.check(strings.Fields(tt.command))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("check(%q) = %v, wantErr %v", tt.command, err, tt.wantErr)
+		}
+	}
+}
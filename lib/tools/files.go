@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// ReadFileTool returns the ReadFile tool, reading files from fsys
+func ReadFileTool(fsys *FS) Tool {
+	return New("ReadFile",
+		"Reads the contents of a file in the data directory",
+		llm.StringInputSchema("path", "Relative path from the data directory, e.g. internal/handler/user.go"),
+		func(ctx context.Context, path string) (string, error) {
+			if path == "" {
+				return "", errors.New("a file path is required as Action Input")
+			}
+			content, err := fsys.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Content of %s:\n%s", path, content), nil
+		})
+}
+
+// DefaultMaxBytes bounds the output of ReadFileRange
+const DefaultMaxBytes = 16 * 1024
+
+var readFileRangeSchema = json.RawMessage(`{"type":"object","properties":{` +
+	`"path":{"type":"string","description":"Relative path from the data directory"},` +
+	`"start":{"type":"integer","description":"First line to read, starting at 1"},` +
+	`"end":{"type":"integer","description":"Last line to read; omit to read to the end of the file"}},` +
+	`"required":["path","start"]}`)
+
+// ReadFileRangeTool returns the ReadFileRange tool, which reads numbered lines from a file in fsys.
+// Output over maxBytes is cut with a notice telling the model where to continue.
+func ReadFileRangeTool(fsys *FS, maxBytes int) Tool {
+	return New("ReadFileRange",
+		"Reads a range of lines from a file with line numbers. Use it to page through large files.",
+		readFileRangeSchema,
+		func(ctx context.Context, input string) (string, error) {
+			var in struct {
+				Path  string `json:"path"`
+				Start int    `json:"start"`
+				End   int    `json:"end"`
+			}
+			// A bare path reads the file from the top
+			if strings.HasPrefix(strings.TrimSpace(input), "{") {
+				if err := json.Unmarshal([]byte(input), &in); err != nil {
+					return "", fmt.Errorf("invalid input: %w", err)
+				}
+			} else {
+				in.Path = input
+			}
+			if in.Path == "" {
+				return "", errors.New("a file path is required")
+			}
+			return fsys.ReadLines(in.Path, in.Start, in.End, maxBytes)
+		})
+}
+
+// ListFilesTool returns the ListFiles tool, listing the files at the top of fsys
+func ListFilesTool(fsys *FS) Tool {
+	return New("ListFiles",
+		"Lists the files at the top level of the data directory",
+		llm.NoInputSchema,
+		func(ctx context.Context, _ string) (string, error) {
+			return fsys.ListFiles()
+		})
+}
+
+// ListFilesTreeTool returns the ListFiles tool, showing the whole of fsys as a tree
+func ListFilesTreeTool(fsys *FS) Tool {
+	return New("ListFiles",
+		"Displays all files and directories under the data directory in tree format",
+		llm.NoInputSchema,
+		func(ctx context.Context, _ string) (string, error) {
+			return fsys.ListFilesTree()
+		})
+}
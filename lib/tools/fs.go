@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// FS is the directory tree the file tools can read. Paths are relative to its root;
+// absolute paths, ".." and symlinks leading outside the root are rejected.
+type FS struct {
+	fsys fs.FS
+	// name is shown as the root of listings
+	name string
+	root *os.Root
+}
+
+// OpenFS opens dir as the root of an FS
+func OpenFS(dir string) (*FS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data directory: %w", err)
+	}
+	return &FS{fsys: root.FS(), name: filepath.Base(dir), root: root}, nil
+}
+
+// DataDir returns the first of dirs that is an existing directory, or the first one
+// if none is, so an example finds its data when run from its own directory or from
+// the repository root
+func DataDir(dirs ...string) string {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return dirs[0]
+}
+
+// NewFS wraps fsys, which must do its own confinement (embed.FS, fstest.MapFS, os.Root.FS)
+func NewFS(fsys fs.FS, name string) *FS {
+	return &FS{fsys: fsys, name: name}
+}
+
+// Close releases the root opened by OpenFS
+func (f *FS) Close() error {
+	if f.root == nil {
+		return nil
+	}
+	return f.root.Close()
+}
+
+// Dir returns the directory opened by OpenFS, for tools that must run the go command on it
+func (f *FS) Dir() (string, bool) {
+	if f.root == nil {
+		return "", false
+	}
+	return f.root.Name(), true
+}
+
+// Clean converts a path from the model to a path inside the root.
+// "./a" and "a/" are accepted; anything that leaves the root is an error.
+func (f *FS) Clean(name string) (string, error) {
+	name = strings.TrimSpace(filepath.ToSlash(name))
+	if name == "" {
+		return ".", nil
+	}
+	if path.IsAbs(name) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("path %s must be relative to the %s directory", name, f.name)
+	}
+	cleaned := path.Clean(name)
+	if !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("path %s is outside the %s directory", name, f.name)
+	}
+	return cleaned, nil
+}
+
+// ReadFile reads a file from the root and returns its content
+func (f *FS) ReadFile(filename string) (string, error) {
+	name, err := f.Clean(filename)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := fs.ReadFile(f.fsys, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	return string(content), nil
+}
+
+// ListFiles lists the files at the top of the root (flat format)
+func (f *FS) ListFiles() (string, error) {
+	entries, err := fs.ReadDir(f.fsys, ".")
+	if err != nil {
+		return "", fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Sprintf("No files found in %s directory", f.name), nil
+	}
+
+	result := fmt.Sprintf("Files in %s directory:\n", f.name)
+	for _, file := range files {
+		result += fmt.Sprintf("- %s\n", file)
+	}
+
+	return result, nil
+}
+
+// ListFilesTree lists all files and directories under the root in tree format
+func (f *FS) ListFilesTree() (string, error) {
+	var result string
+	result += f.name + "/\n"
+
+	err := fs.WalkDir(f.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == "." {
+			return nil
+		}
+
+		depth := countDepth(p)
+
+		prefix := buildTreePrefix(depth)
+		name := d.Name()
+		if d.IsDir() {
+			name += "/"
+		}
+
+		result += fmt.Sprintf("%s%s\n", prefix, name)
+		return nil
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return result, nil
+}
+
+func countDepth(p string) int {
+	if p == "." || p == "" {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
+
+func buildTreePrefix(depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	prefix := ""
+	for i := 0; i < depth-1; i++ {
+		prefix += "│   "
+	}
+	prefix += "├── "
+	return prefix
+}
+
+// ReadLines returns lines start through end (1-based, inclusive) of a file, numbered.
+// end <= 0 reads to the end of the file. Output stops before exceeding maxBytes
+// (0 for no limit) with a notice of how many lines remain; a first line longer than
+// the limit is cut.
+func (f *FS) ReadLines(filename string, start, end, maxBytes int) (string, error) {
+	content, err := f.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	total := len(lines)
+	if start <= 0 {
+		start = 1
+	}
+	if start > total {
+		return "", fmt.Errorf("start line %d is past the end of %s (%d lines)", start, filename, total)
+	}
+	if end <= 0 || end > total {
+		end = total
+	}
+	if end < start {
+		return "", fmt.Errorf("end line %d is before start line %d", end, start)
+	}
+
+	header := func(last int) string {
+		return fmt.Sprintf("Lines %d-%d of %s (%d lines total):\n", start, last, filename, total)
+	}
+	// The header for end is at least as long as the final one, so it bounds the size
+	size := len(header(end))
+	truncated := func(n int) string {
+		return fmt.Sprintf("[file truncated, %d lines remaining; continue with start %d]\n", total-n+1, n)
+	}
+	// Every line but the last leaves room for the notice, which is longest with both numbers at total
+	reserve := len(truncated(total))
+	var body strings.Builder
+	last := end
+	for n := start; n <= end; n++ {
+		line := fmt.Sprintf("%5d  %s\n", n, lines[n-1])
+		need := len(line)
+		if n < end {
+			need += reserve
+		}
+		if maxBytes <= 0 || size+body.Len()+need <= maxBytes {
+			body.WriteString(line)
+			continue
+		}
+		if n > start {
+			body.WriteString(truncated(n))
+			last = n - 1
+			break
+		}
+
+		// A first line over the limit, such as minified code, is cut to fit
+		notice := fmt.Sprintf("[file truncated: line %d is longer than the limit]\n", n)
+		if n < total {
+			notice = fmt.Sprintf("[file truncated: line %d is longer than the limit, %d lines remaining; continue with start %d]\n", n, total-n, n+1)
+		}
+		cut := min(len(line)-1, max(0, maxBytes-size-len(notice)-1))
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		body.WriteString(line[:cut] + "\n" + notice)
+		last = n
+		break
+	}
+	return header(last) + body.String(), nil
+}
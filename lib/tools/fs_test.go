@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// openTestFS returns an FS over a temporary directory holding files
+func openTestFS(t *testing.T, files map[string]string) (*FS, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys, err := OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fsys.Close() })
+	return fsys, dir
+}
+
+func TestFSConfinement(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys, dir := openTestFS(t, map[string]string{"a": "content of a", "sub/b": "content of b"})
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "a", want: "content of a"},
+		{name: "./a", want: "content of a"},
+		{name: " sub/b ", want: "content of b"},
+		{name: "sub/../a", want: "content of a"},
+		{name: "../x", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: "a/../../x", wantErr: true},
+		{name: "link", wantErr: true},
+		{name: "linkdir/secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fsys.ReadFile(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFile(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReadFile(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSClean(t *testing.T) {
+	fsys, _ := openTestFS(t, nil)
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "", want: "."},
+		{name: "./a", want: "a"},
+		{name: "a/", want: "a"},
+		{name: "../x", wantErr: "outside"},
+		{name: "a/../../x", wantErr: "outside"},
+		{name: "/etc/passwd", wantErr: "must be relative"},
+	}
+	for _, tt := range tests {
+		got, err := fsys.Clean(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Clean(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Clean(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	fsys, _ := openTestFS(t, map[string]string{
+		"ten.txt":     "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\nline 9\nline 10\n",
+		"minified.js": strings.Repeat("x", 5000) + "\nnext\n",
+		"oneline.js":  strings.Repeat("é", 2500),
+	})
+
+	t.Run("range", func(t *testing.T) {
+		got, err := fsys.ReadLines("ten.txt", 2, 3, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "Lines 2-3 of ten.txt (10 lines total):\n    2  line 2\n    3  line 3\n"
+		if got != want {
+			t.Errorf("ReadLines() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		got, err := fsys.ReadLines("ten.txt", 1, 0, 160)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) > 160 {
+			t.Errorf("output is %d bytes, over the limit", len(got))
+		}
+		want := "Lines 1-4 of ten.txt (10 lines total):\n    1  line 1\n    2  line 2\n    3  line 3\n    4  line 4\n" +
+			"[file truncated, 6 lines remaining; continue with start 5]\n"
+		if got != want {
+			t.Errorf("ReadLines() = %q, want %q", got, want)
+		}
+
+		next, err := fsys.ReadLines("ten.txt", 5, 0, 160)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(next, "Lines 5-8 of ten.txt") || !strings.Contains(next, "    5  line 5\n") || len(next) > 160 {
+			t.Errorf("next page = %q, want it to continue at line 5", next)
+		}
+	})
+
+	t.Run("long first line", func(t *testing.T) {
+		for _, name := range []string{"minified.js", "oneline.js"} {
+			got, err := fsys.ReadLines(name, 1, 0, 300)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) > 300 {
+				t.Errorf("%s: output is %d bytes, over the limit", name, len(got))
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("%s: output cuts a rune in half", name)
+			}
+			if !strings.Contains(got, "[file truncated: line 1 is longer than the limit") {
+				t.Errorf("%s: output %q has no truncation notice", name, got)
+			}
+		}
+		got, _ := fsys.ReadLines("minified.js", 1, 0, 300)
+		if !strings.HasSuffix(got, "1 lines remaining; continue with start 2]\n") {
+			t.Errorf("output %q should continue with the next line", got)
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		if _, err := fsys.ReadLines("ten.txt", 5, 3, 0); err == nil || !strings.Contains(err.Error(), "before start") {
+			t.Errorf("end < start error = %v", err)
+		}
+		if _, err := fsys.ReadLines("ten.txt", 11, 0, 0); err == nil || !strings.Contains(err.Error(), "past the end") {
+			t.Errorf("start > total error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// Symbol is a function, method or type declared in a Go file
+type Symbol struct {
+	// Name is "Func", "Type" or "Type.Method"
+	Name string
+	// Kind is "func", "method" or "type"
+	Kind string
+	// Signature is the declaration without its body
+	Signature string
+	// Doc is the first line of the doc comment
+	Doc       string
+	Path      string
+	StartLine int
+	EndLine   int
+	// Source is the full declaration including its doc comment
+	Source string
+}
+
+// GoSymbols parses the Go files under dir ("." for the whole root) and returns their
+// top-level functions, methods and types in file order. Symlinks are ignored, and files
+// that cannot be read or do not parse are skipped and returned with their errors.
+func (f *FS) GoSymbols(dir string) (symbols []Symbol, skipped []string, err error) {
+	root, err := f.Clean(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = fs.WalkDir(f.fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(p) != ".go" || !d.Type().IsRegular() {
+			return nil
+		}
+
+		src, err := fs.ReadFile(f.fsys, p)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			return nil
+		}
+		found, err := parseSymbols(p, src)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			return nil
+		}
+		symbols = append(symbols, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list Go symbols: %w", err)
+	}
+	return symbols, skipped, nil
+}
+
+// skippedNote lists the files a tool skipped because they cannot be read or do not parse
+func skippedNote(skipped []string) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+	return "\nSkipped files that cannot be read or do not parse:\n  " + strings.Join(skipped, "\n  ") + "\n"
+}
+
+func parseSymbols(filename string, src []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	add := func(s Symbol, doc *ast.CommentGroup, node ast.Node) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		s.Path = filename
+		s.StartLine = fset.Position(start).Line
+		s.EndLine = fset.Position(node.End()).Line
+		s.Source = string(src[fset.Position(start).Offset:fset.Position(node.End()).Offset])
+		if doc != nil {
+			s.Doc, _, _ = strings.Cut(strings.TrimSpace(doc.Text()), "\n")
+		}
+		symbols = append(symbols, s)
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			s := Symbol{Name: decl.Name.Name, Kind: "func"}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				s.Name = receiverType(decl.Recv.List[0].Type) + "." + decl.Name.Name
+				s.Kind = "method"
+			}
+			// Print the declaration without its body or doc comment
+			sig := *decl
+			sig.Body = nil
+			sig.Doc = nil
+			s.Signature = printNode(fset, &sig)
+			add(s, decl.Doc, decl)
+
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				doc := spec.Doc
+				var node ast.Node = spec
+				// A lone type declaration keeps its doc comment on the GenDecl
+				if len(decl.Specs) == 1 {
+					doc = decl.Doc
+					node = decl
+				}
+				add(Symbol{Name: spec.Name.Name, Kind: "type", Signature: typeSignature(fset, spec)}, doc, node)
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// receiverType returns the type name of a method receiver, without pointer or type parameters
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// typeSignature summarizes a type spec, leaving out struct fields and interface methods
+func typeSignature(fset *token.FileSet, spec *ast.TypeSpec) string {
+	assign := " "
+	if spec.Assign.IsValid() {
+		assign = " = "
+	}
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return "type " + spec.Name.Name + assign + "struct"
+	case *ast.InterfaceType:
+		return "type " + spec.Name.Name + assign + "interface"
+	}
+	return "type " + spec.Name.Name + assign + printNode(fset, spec.Type)
+}
+
+func printNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+var listSymbolsSchema = json.RawMessage(`{"type":"object","properties":{` +
+	`"path":{"type":"string","description":"File or directory to list, relative to the data directory; . for everything"}}}`)
+
+// ListSymbolsTool returns the ListSymbols tool, which lists the functions, methods and
+// types declared in the Go files of fsys with their signatures and doc comments
+func ListSymbolsTool(fsys *FS) Tool {
+	return New("ListSymbols",
+		"Lists the functions, methods and types declared in Go files with their signatures and doc comments",
+		listSymbolsSchema,
+		func(ctx context.Context, input string) (string, error) {
+			dir := strings.TrimSpace(input)
+			if dir == "none" {
+				dir = ""
+			}
+			symbols, skipped, err := fsys.GoSymbols(dir)
+			if err != nil {
+				return "", err
+			}
+			if len(symbols) == 0 {
+				return "No Go declarations found" + skippedNote(skipped), nil
+			}
+
+			var b strings.Builder
+			file := ""
+			for _, s := range symbols {
+				if s.Path != file {
+					file = s.Path
+					fmt.Fprintf(&b, "%s:\n", file)
+				}
+				fmt.Fprintf(&b, "  %d: %s", s.StartLine, s.Signature)
+				if s.Doc != "" {
+					fmt.Fprintf(&b, "  // %s", s.Doc)
+				}
+				b.WriteString("\n")
+			}
+			return b.String() + skippedNote(skipped), nil
+		})
+}
+
+// GetSymbolTool returns the GetSymbol tool, which returns the exact source of a named
+// function, type or method (Type.Method) in the Go files of fsys
+func GetSymbolTool(fsys *FS) Tool {
+	return New("GetSymbol",
+		"Returns the exact source of a Go function, type or method, including its doc comment",
+		llm.StringInputSchema("name", "Symbol name, e.g. Add, Calculator or Calculator.Sum"),
+		func(ctx context.Context, input string) (string, error) {
+			// Accept receiver syntax such as (*Calculator).Sum
+			name := strings.NewReplacer("(", "", ")", "", "*", "").Replace(strings.TrimSpace(input))
+			if name == "" {
+				return "", errors.New("a symbol name is required")
+			}
+			symbols, skipped, err := fsys.GoSymbols(".")
+			if err != nil {
+				return "", err
+			}
+
+			var b strings.Builder
+			for _, s := range symbols {
+				if s.Name != name {
+					continue
+				}
+				fmt.Fprintf(&b, "%s:%d-%d:\n%s\n\n", s.Path, s.StartLine, s.EndLine, s.Source)
+			}
+			if b.Len() == 0 {
+				return "", fmt.Errorf("symbol %s not found; use ListSymbols to see the declared names%s", name, skippedNote(skipped))
+			}
+			return strings.TrimSuffix(b.String(), "\n"), nil
+		})
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoSymbolsSkipsBrokenFiles(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "x.go"), []byte("package x\n\nfunc Outside() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":      "package a\n\n// Hello greets\nfunc Hello() {}\n\ntype T struct{}\n",
+		"broken.go": "package a\n\nfunc {\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "x.go"), filepath.Join(dir, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	symbols, skipped, err := fsys.GoSymbols(".")
+	if err != nil {
+		t.Fatalf("GoSymbols: %v", err)
+	}
+	var names []string
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, " ") != "Hello T" {
+		t.Errorf("symbols = %v, want Hello T", names)
+	}
+	if symbols[0].StartLine != 3 || symbols[0].EndLine != 4 {
+		t.Errorf("Hello spans lines %d-%d, want 3-4 including its doc comment", symbols[0].StartLine, symbols[0].EndLine)
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0], "broken.go:") {
+		t.Errorf("skipped = %v, want broken.go", skipped)
+	}
+}
@@ -0,0 +1,437 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Queries answered by QuerySymbol
+const (
+	QueryReferences      = "references"
+	QueryImplementations = "implementations"
+	QueryCallers         = "callers"
+	QueryCallees         = "callees"
+)
+
+// Program is the type-checked Go code of a directory, loaded with go/packages
+type Program struct {
+	fsys *FS
+	dir  string
+	pkgs []*packages.Package
+}
+
+// LoadProgram type-checks the packages in the directory of fsys. The go command runs
+// offline with GOPROXY=off, so dependencies must be vendored or already in the module
+// cache; packages with missing imports are still loaded with partial type information.
+func LoadProgram(ctx context.Context, fsys *FS) (*Program, error) {
+	dir, ok := fsys.Dir()
+	if !ok {
+		return nil, errors.New("loading Go packages needs a data directory on disk")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	// GOFLAGS is cleared so vendor/ is used when present and go.mod is never rewritten
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+		Dir:   abs,
+		Env:   append(os.Environ(), "GOPROXY=off", "GOFLAGS=", "GOWORK=off"),
+		Tests: false,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Go packages: %w", err)
+	}
+	if len(pkgs) == 0 {
+		return nil, errors.New("no Go packages found")
+	}
+	return &Program{fsys: fsys, dir: abs, pkgs: pkgs}, nil
+}
+
+// ReadFile reads a file reported by go/packages through the FS, so the root's
+// confinement also applies to positions in the loaded syntax
+func (p *Program) ReadFile(filename string) (string, error) {
+	rel, err := filepath.Rel(p.dir, filename)
+	if err != nil {
+		return "", err
+	}
+	return p.fsys.ReadFile(filepath.ToSlash(rel))
+}
+
+// Query answers one of the Query* kinds for a symbol such as "UpdateStock",
+// "service.UpdateStock" or "Server.Start", one result per line
+func (p *Program) Query(kind, symbol string) ([]string, error) {
+	obj, err := p.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case QueryReferences:
+		return p.references(obj), nil
+	case QueryImplementations:
+		return p.implementations(obj)
+	case QueryCallers:
+		return p.callers(obj), nil
+	case QueryCallees:
+		return p.callees(obj)
+	}
+	return nil, fmt.Errorf("unknown query %s; use %s, %s, %s or %s",
+		kind, QueryReferences, QueryImplementations, QueryCallers, QueryCallees)
+}
+
+// Packages returns the loaded packages
+func (p *Program) Packages() []*packages.Package {
+	return p.pkgs
+}
+
+// Lookup resolves a possibly qualified name to a package-level object or method
+func (p *Program) Lookup(symbol string) (types.Object, error) {
+	qualifier, name, qualified := strings.Cut(strings.NewReplacer("(", "", ")", "", "*", "").Replace(symbol), ".")
+	if !qualified {
+		qualifier, name = "", qualifier
+	}
+
+	var found []types.Object
+	for _, pkg := range p.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		switch {
+		case !qualified:
+			if obj := scope.Lookup(name); obj != nil {
+				found = append(found, obj)
+			}
+		case pkg.Name == qualifier:
+			if obj := scope.Lookup(name); obj != nil {
+				found = append(found, obj)
+			}
+		default:
+			// Type.Method
+			if tn, ok := scope.Lookup(qualifier).(*types.TypeName); ok {
+				obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(tn.Type()), true, pkg.Types, name)
+				if obj != nil {
+					found = append(found, obj)
+				}
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("symbol %s not found; use ListSymbols to see the declared names", symbol)
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, obj := range found {
+		names[i] = obj.Pkg().Name() + "." + obj.Name()
+	}
+	return nil, fmt.Errorf("symbol %s is ambiguous; qualify it as one of %s", symbol, strings.Join(names, ", "))
+}
+
+func (p *Program) references(obj types.Object) []string {
+	var refs []string
+	for _, pkg := range p.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if ok && pkg.TypesInfo.Uses[id] == obj {
+					refs = append(refs, p.describe(pkg, id.Pos(), enclosingFunc(file, id.Pos())))
+				}
+				return true
+			})
+		}
+	}
+	return sorted(refs)
+}
+
+// implementations lists the types implementing an interface, or the interfaces a type implements
+func (p *Program) implementations(obj types.Object) ([]string, error) {
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type", obj.Name())
+	}
+	iface, isInterface := tn.Type().Underlying().(*types.Interface)
+
+	candidates := p.typeNames()
+	if !isInterface {
+		// Types usually implement interfaces of their imports, such as http.ResponseWriter
+		candidates = append(candidates, p.importedTypeNames()...)
+	}
+
+	var results []string
+	for _, other := range candidates {
+		if other == tn {
+			continue
+		}
+		if isInterface {
+			if _, ok := other.Type().Underlying().(*types.Interface); ok {
+				continue
+			}
+			if types.Implements(other.Type(), iface) || types.Implements(types.NewPointer(other.Type()), iface) {
+				results = append(results, p.position(other.Pos())+": "+qualifiedName(other))
+			}
+			continue
+		}
+		otherIface, ok := other.Type().Underlying().(*types.Interface)
+		if ok && !otherIface.Empty() && (types.Implements(tn.Type(), otherIface) || types.Implements(types.NewPointer(tn.Type()), otherIface)) {
+			results = append(results, p.position(other.Pos())+": "+qualifiedName(other))
+		}
+	}
+	return sorted(results), nil
+}
+
+func (p *Program) callers(obj types.Object) []string {
+	var callers []string
+	p.eachCall(func(pkg *packages.Package, caller *ast.FuncDecl, call *ast.CallExpr, callee *types.Func) {
+		if callee == obj || callee.Origin() == obj {
+			callers = append(callers, p.describe(pkg, call.Pos(), caller))
+		}
+	})
+	return sorted(callers)
+}
+
+func (p *Program) callees(obj types.Object) ([]string, error) {
+	if _, ok := obj.(*types.Func); !ok {
+		return nil, fmt.Errorf("%s is not a function or method", obj.Name())
+	}
+	seen := make(map[string]bool)
+	var callees []string
+	p.eachCall(func(pkg *packages.Package, caller *ast.FuncDecl, call *ast.CallExpr, callee *types.Func) {
+		if caller == nil || pkg.TypesInfo.Defs[caller.Name] != obj {
+			return
+		}
+		name := qualifiedName(callee)
+		if !seen[name] {
+			seen[name] = true
+			callees = append(callees, fmt.Sprintf("%s (called at %s)", name, p.position(call.Pos())))
+		}
+	})
+	return callees, nil
+}
+
+// eachCall visits every static call in the loaded packages
+func (p *Program) eachCall(fn func(pkg *packages.Package, caller *ast.FuncDecl, call *ast.CallExpr, callee *types.Func)) {
+	for _, pkg := range p.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				caller, _ := decl.(*ast.FuncDecl)
+				ast.Inspect(decl, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					if callee := typeutil.StaticCallee(pkg.TypesInfo, call); callee != nil {
+						fn(pkg, caller, call, callee)
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+func (p *Program) typeNames() []*types.TypeName {
+	var names []*types.TypeName
+	for _, pkg := range p.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			if tn, ok := scope.Lookup(name).(*types.TypeName); ok && !tn.IsAlias() {
+				names = append(names, tn)
+			}
+		}
+	}
+	return names
+}
+
+// importedTypeNames returns the exported types of packages imported by the loaded ones
+func (p *Program) importedTypeNames() []*types.TypeName {
+	loaded := make(map[*types.Package]bool)
+	for _, pkg := range p.pkgs {
+		loaded[pkg.Types] = true
+	}
+	seen := make(map[*types.Package]bool)
+	var names []*types.TypeName
+	for _, pkg := range p.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		for _, imp := range pkg.Types.Imports() {
+			if loaded[imp] || seen[imp] {
+				continue
+			}
+			seen[imp] = true
+			scope := imp.Scope()
+			for _, name := range scope.Names() {
+				if tn, ok := scope.Lookup(name).(*types.TypeName); ok && tn.Exported() && !tn.IsAlias() {
+					names = append(names, tn)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// describe formats a position with its enclosing function and source line
+func (p *Program) describe(pkg *packages.Package, pos token.Pos, fn *ast.FuncDecl) string {
+	where := p.position(pos)
+	if fn != nil {
+		if obj := pkg.TypesInfo.Defs[fn.Name]; obj != nil {
+			where += " in " + qualifiedName(obj)
+		}
+	}
+	return where + ": " + p.sourceLine(pkg, pos)
+}
+
+func (p *Program) position(pos token.Pos) string {
+	for _, pkg := range p.pkgs {
+		if pkg.Fset == nil {
+			continue
+		}
+		position := pkg.Fset.Position(pos)
+		if !position.IsValid() {
+			continue
+		}
+		rel, err := filepath.Rel(p.dir, position.Filename)
+		if err != nil {
+			rel = position.Filename
+		}
+		return fmt.Sprintf("%s:%d", filepath.ToSlash(rel), position.Line)
+	}
+	return "?"
+}
+
+func (p *Program) sourceLine(pkg *packages.Package, pos token.Pos) string {
+	position := pkg.Fset.Position(pos)
+	content, err := p.ReadFile(position.Filename)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(content, "\n")
+	if position.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[position.Line-1])
+}
+
+func enclosingFunc(file *ast.File, pos token.Pos) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos < fn.End() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// qualifiedName formats pkg.Name or pkg.Type.Method
+func qualifiedName(obj types.Object) string {
+	name := obj.Name()
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Signature().Recv(); recv != nil {
+			t := recv.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if named, ok := t.(*types.Named); ok {
+				name = named.Obj().Name() + "." + name
+			}
+		}
+	}
+	if obj.Pkg() == nil {
+		return name
+	}
+	return obj.Pkg().Name() + "." + name
+}
+
+func sorted(lines []string) []string {
+	sort.Strings(lines)
+	return lines
+}
+
+var querySymbolSchema = json.RawMessage(`{"type":"object","properties":{` +
+	`"query":{"type":"string","enum":["references","implementations","callers","callees"],"description":"references, implementations, callers or callees"},` +
+	`"symbol":{"type":"string","description":"Symbol name, optionally qualified by package or type, e.g. service.UpdateStock or Server.Start"}},` +
+	`"required":["query","symbol"]}`)
+
+// QuerySymbolTool returns the QuerySymbol tool, which answers type-checked references,
+// implementations, callers and callees queries over the Go module in fsys.
+// The module is loaded on first use and reused after a successful load.
+func QuerySymbolTool(fsys *FS) Tool {
+	var (
+		mu      sync.Mutex
+		program *Program
+	)
+	load := func(ctx context.Context) (*Program, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if program == nil {
+			loaded, err := LoadProgram(ctx, fsys)
+			if err != nil {
+				return nil, err
+			}
+			program = loaded
+		}
+		return program, nil
+	}
+	return New("QuerySymbol",
+		"Finds references, implementations, callers or callees of a Go symbol using the type checker, across all files",
+		querySymbolSchema,
+		func(ctx context.Context, input string) (string, error) {
+			var in struct {
+				Query  string `json:"query"`
+				Symbol string `json:"symbol"`
+			}
+			// Text actions may also write "callers service.UpdateStock"
+			if strings.HasPrefix(strings.TrimSpace(input), "{") {
+				if err := json.Unmarshal([]byte(input), &in); err != nil {
+					return "", fmt.Errorf("invalid input: %w", err)
+				}
+			} else {
+				in.Query, in.Symbol, _ = strings.Cut(strings.TrimSpace(input), " ")
+			}
+			if in.Query == "" || in.Symbol == "" {
+				return "", errors.New("both query and symbol are required")
+			}
+
+			program, err := load(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			results, err := program.Query(in.Query, strings.TrimSpace(in.Symbol))
+			if err != nil {
+				return "", err
+			}
+			if len(results) == 0 {
+				return fmt.Sprintf("No %s found for %s", in.Query, in.Symbol), nil
+			}
+			return fmt.Sprintf("%s of %s:\n%s", in.Query, in.Symbol, strings.Join(results, "\n")), nil
+		})
+}
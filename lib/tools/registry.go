@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// PromptPlaceholder is replaced by the Available Tools section in Registry.Prompt
+const PromptPlaceholder = "{{tools}}"
+
+// Registry holds the tools of an agent. It dispatches actions and describes the
+// tools to the model, so the prompt and the implementation can't drift apart.
+type Registry struct {
+	tools map[string]Tool
+	// order keeps registration order for the prompt
+	order []string
+}
+
+// NewRegistry returns a registry holding tools
+func NewRegistry(tools ...Tool) *Registry {
+	r := &Registry{tools: make(map[string]Tool)}
+	for _, t := range tools {
+		r.Register(t)
+	}
+	return r
+}
+
+// Register adds t, replacing any tool with the same name
+func (r *Registry) Register(t Tool) {
+	if _, ok := r.tools[t.Name()]; !ok {
+		r.order = append(r.order, t.Name())
+	}
+	r.tools[t.Name()] = t
+}
+
+// Get returns the tool named name
+func (r *Registry) Get(name string) (Tool, bool) {
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Names returns the tool names in registration order
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+// Execute runs the named tool and returns the observation text.
+// Unknown actions and tool errors become error observations with failed set.
+func (r *Registry) Execute(ctx context.Context, name, input string) (observation string, failed bool) {
+	t, ok := r.tools[name]
+	if !ok {
+		names := r.Names()
+		sort.Strings(names)
+		return fmt.Sprintf("Error: Unknown action '%s'. Available actions: %s", name, strings.Join(names, ", ")), true
+	}
+	observation, err := t.Execute(ctx, input)
+	if err != nil {
+		return fmt.Sprintf("Error: %s: %v", name, err), true
+	}
+	return observation, false
+}
+
+// Specs returns the tool definitions sent to the model in native mode
+func (r *Registry) Specs() []llm.ToolSpec {
+	specs := make([]llm.ToolSpec, 0, len(r.order))
+	for _, name := range r.order {
+		t := r.tools[name]
+		specs = append(specs, llm.ToolSpec{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: t.InputSchema(),
+		})
+	}
+	return specs
+}
+
+// Prompt replaces PromptPlaceholder in template with PromptSection
+func (r *Registry) Prompt(template string) string {
+	return strings.ReplaceAll(template, PromptPlaceholder, r.PromptSection())
+}
+
+// PromptSection renders the "Available Tools" section of a text-mode system prompt
+func (r *Registry) PromptSection() string {
+	var b strings.Builder
+	b.WriteString("## Available Tools\n")
+	for i, name := range r.order {
+		t := r.tools[name]
+		fmt.Fprintf(&b, "\n### %d. %s\n", i+1, name)
+		fmt.Fprintf(&b, "**Function**: %s\n", t.Description())
+		fmt.Fprintf(&b, "**Usage**:\n  Action: %s\n  Action Input: %s\n", name, inputUsage(t.InputSchema()))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// inputUsage describes the Action Input expected by a schema
+func inputUsage(schema json.RawMessage) string {
+	var s struct {
+		Properties map[string]struct {
+			Type        string `json:"type"`
+			Description string `json:"description"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &s); err != nil || len(s.Properties) == 0 {
+		return "none"
+	}
+
+	if len(s.Properties) == 1 {
+		for _, p := range s.Properties {
+			if p.Type == "string" {
+				return "[" + p.Description + "]"
+			}
+		}
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	fields := make([]string, 0, len(names))
+	for _, name := range names {
+		p := s.Properties[name]
+		field := fmt.Sprintf("%q (%s", name, p.Type)
+		if !required[name] {
+			field += ", optional"
+		}
+		field += ")"
+		if p.Description != "" {
+			field += ": " + p.Description
+		}
+		fields = append(fields, field)
+	}
+	return "[a JSON object on one line with " + strings.Join(fields, "; ") + "]"
+}
@@ -0,0 +1,394 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// Route is an HTTP handler registration found by Routes
+type Route struct {
+	// Method is empty when the route accepts any method
+	Method  string
+	Path    string
+	Handler string
+	// Middleware is the chain applied by Use on the router, outermost first
+	Middleware []string
+	File       string
+	Line       int
+}
+
+// router is a mux variable within one function
+type router struct {
+	prefix     string
+	parent     string
+	middleware []string
+}
+
+// routeScanner extracts the routes of one package directory
+type routeScanner struct {
+	fset  *token.FileSet
+	decls []*ast.FuncDecl
+	// called holds the functions passed a router by another function of the package;
+	// their routes are reported through the caller, with its prefix and middleware
+	called map[*ast.FuncDecl]bool
+	// active holds the functions being scanned, so recursion stops
+	active map[*ast.FuncDecl]bool
+}
+
+// Routes statically extracts the routes registered in the Go files of the root:
+// gorilla/mux r.HandleFunc(...).Methods(...), r.Handle, r.PathPrefix(...).Subrouter()
+// and r.Use middleware, and net/http http.HandleFunc / mux.HandleFunc including
+// Go 1.22 "METHOD /path" patterns. A router passed to a function or method of the same
+// package carries its prefix and middleware into it. Registrations are matched by shape,
+// without type checking. Symlinks are ignored, and files that cannot be read or do not
+// parse are skipped and returned with their errors.
+func (f *FS) Routes() (routes []Route, skipped []string, err error) {
+	fset := token.NewFileSet()
+	scanners := make(map[string]*routeScanner)
+	var dirs []string
+	err = fs.WalkDir(f.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(p) != ".go" || strings.HasSuffix(p, "_test.go") || !d.Type().IsRegular() {
+			return nil
+		}
+
+		src, err := fs.ReadFile(f.fsys, p)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			return nil
+		}
+		file, err := parser.ParseFile(fset, p, src, 0)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			return nil
+		}
+		dir := path.Dir(p)
+		s, ok := scanners[dir]
+		if !ok {
+			s = &routeScanner{fset: fset, called: make(map[*ast.FuncDecl]bool), active: make(map[*ast.FuncDecl]bool)}
+			scanners[dir] = s
+			dirs = append(dirs, dir)
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				s.decls = append(s.decls, fn)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract routes: %w", err)
+	}
+	for _, dir := range dirs {
+		routes = append(routes, scanners[dir].routes()...)
+	}
+	return routes, skipped, nil
+}
+
+// routes returns the routes of every function not reached through a caller
+func (s *routeScanner) routes() []Route {
+	found := make([][]Route, len(s.decls))
+	for i, fn := range s.decls {
+		found[i] = s.funcRoutes(fn, nil)
+	}
+	var routes []Route
+	for i, fn := range s.decls {
+		if !s.called[fn] {
+			routes = append(routes, found[i]...)
+		}
+	}
+	return routes
+}
+
+// helperCall is a call passing routers to a function of the package.
+// routers maps a parameter name to the caller's router.
+type helperCall struct {
+	callee  *ast.FuncDecl
+	routers map[string]string
+}
+
+// funcRoutes extracts the routes registered in one function, where inherited holds
+// the routers received as parameters. Use calls apply to every route of their router
+// whatever the statement order, as with gorilla/mux.
+func (s *routeScanner) funcRoutes(fn *ast.FuncDecl, inherited map[string]*router) []Route {
+	s.active[fn] = true
+	defer delete(s.active, fn)
+
+	routers := make(map[string]*router)
+	for name, r := range inherited {
+		routers[name] = r
+	}
+	getRouter := func(name string) *router {
+		if routers[name] == nil {
+			routers[name] = &router{}
+		}
+		return routers[name]
+	}
+
+	type registration struct {
+		router  string
+		route   Route
+		methods []string
+	}
+	var registrations []registration
+	var calls []helperCall
+	handled := make(map[*ast.CallExpr]bool)
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			// r := mux.NewRouter() or s := r.PathPrefix("/api").Subrouter()
+			if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			name, ok := n.Lhs[0].(*ast.Ident)
+			call, isCall := n.Rhs[0].(*ast.CallExpr)
+			if !ok || !isCall {
+				return true
+			}
+			switch selectorName(call.Fun) {
+			case "NewRouter", "NewServeMux":
+				routers[name.Name] = &router{}
+				return true
+			case "Subrouter":
+			default:
+				return true
+			}
+			r := getRouter(name.Name)
+			for expr := call.Fun.(*ast.SelectorExpr).X; ; {
+				inner, ok := expr.(*ast.CallExpr)
+				if !ok {
+					if parent, ok := expr.(*ast.Ident); ok {
+						r.parent = parent.Name
+					}
+					break
+				}
+				if selectorName(inner.Fun) == "PathPrefix" && len(inner.Args) == 1 {
+					r.prefix = stringLit(inner.Args[0]) + r.prefix
+				}
+				expr = inner.Fun.(*ast.SelectorExpr).X
+			}
+
+		case *ast.CallExpr:
+			if handled[n] {
+				return true
+			}
+			switch selectorName(n.Fun) {
+			case "Use":
+				if name, ok := n.Fun.(*ast.SelectorExpr).X.(*ast.Ident); ok {
+					r := getRouter(name.Name)
+					for _, arg := range n.Args {
+						r.middleware = append(r.middleware, exprString(s.fset, arg))
+					}
+				}
+
+			case "Methods":
+				// r.HandleFunc(...).Methods("GET", "HEAD")
+				inner, ok := n.Fun.(*ast.SelectorExpr).X.(*ast.CallExpr)
+				if !ok || !isRegistration(inner) {
+					return true
+				}
+				handled[inner] = true
+				name, route := parseRegistration(s.fset, inner)
+				var methods []string
+				for _, arg := range n.Args {
+					methods = append(methods, stringLit(arg))
+				}
+				registrations = append(registrations, registration{router: name, route: route, methods: methods})
+
+			default:
+				if isRegistration(n) {
+					name, route := parseRegistration(s.fset, n)
+					registrations = append(registrations, registration{router: name, route: route})
+				} else if call, ok := s.helperCall(n, routers); ok {
+					calls = append(calls, call)
+				}
+			}
+		}
+		return true
+	})
+
+	var routes []Route
+	for _, reg := range registrations {
+		prefix, middleware := chain(routers, reg.router, make(map[string]bool))
+		route := reg.route
+		route.Path = prefix + route.Path
+		route.Middleware = middleware
+		if len(reg.methods) == 0 {
+			routes = append(routes, route)
+			continue
+		}
+		for _, method := range reg.methods {
+			route.Method = method
+			routes = append(routes, route)
+		}
+	}
+	for _, call := range calls {
+		received := make(map[string]*router)
+		for param, name := range call.routers {
+			prefix, middleware := chain(routers, name, make(map[string]bool))
+			received[param] = &router{prefix: prefix, middleware: middleware}
+		}
+		s.called[call.callee] = true
+		routes = append(routes, s.funcRoutes(call.callee, received)...)
+	}
+	return routes
+}
+
+// helperCall matches a call of a function or method of the package that is passed
+// a known router. Without type checking, a name declared more than once is ignored.
+func (s *routeScanner) helperCall(call *ast.CallExpr, routers map[string]*router) (helperCall, bool) {
+	var name string
+	method := false
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		name = fun.Name
+	case *ast.SelectorExpr:
+		name, method = fun.Sel.Name, true
+	default:
+		return helperCall{}, false
+	}
+
+	var callee *ast.FuncDecl
+	for _, fn := range s.decls {
+		if fn.Name.Name == name && (fn.Recv != nil) == method {
+			if callee != nil {
+				return helperCall{}, false
+			}
+			callee = fn
+		}
+	}
+	if callee == nil || s.active[callee] {
+		return helperCall{}, false
+	}
+
+	var params []string
+	for _, field := range callee.Type.Params.List {
+		for _, param := range field.Names {
+			params = append(params, param.Name)
+		}
+	}
+	passed := make(map[string]string)
+	for i, arg := range call.Args {
+		if id, ok := arg.(*ast.Ident); ok && routers[id.Name] != nil && i < len(params) {
+			passed[params[i]] = id.Name
+		}
+	}
+	if len(passed) == 0 {
+		return helperCall{}, false
+	}
+	return helperCall{callee: callee, routers: passed}, true
+}
+
+// chain returns the path prefix and middleware of a router including its parents.
+// visited stops the walk when reassigned routers end up as each other's parent.
+func chain(routers map[string]*router, name string, visited map[string]bool) (string, []string) {
+	r, ok := routers[name]
+	if !ok || visited[name] {
+		return "", nil
+	}
+	visited[name] = true
+	prefix, middleware := chain(routers, r.parent, visited)
+	return prefix + r.prefix, append(append([]string(nil), middleware...), r.middleware...)
+}
+
+func isRegistration(call *ast.CallExpr) bool {
+	name := selectorName(call.Fun)
+	return (name == "HandleFunc" || name == "Handle") && len(call.Args) == 2
+}
+
+// parseRegistration returns the router and route of a HandleFunc or Handle call
+func parseRegistration(fset *token.FileSet, call *ast.CallExpr) (string, Route) {
+	sel := call.Fun.(*ast.SelectorExpr)
+	routerName := exprString(fset, sel.X)
+
+	pattern := stringLit(call.Args[0])
+	if pattern == "" {
+		// A path built at runtime is shown as written
+		pattern = exprString(fset, call.Args[0])
+	}
+	route := Route{Path: pattern, Handler: exprString(fset, call.Args[1])}
+	// Go 1.22 net/http patterns: "GET /users/{id}"
+	if method, rest, ok := strings.Cut(pattern, " "); ok && !strings.Contains(method, "/") {
+		route.Method = method
+		route.Path = strings.TrimSpace(rest)
+	}
+
+	position := fset.Position(call.Pos())
+	route.File = position.Filename
+	route.Line = position.Line
+	return routerName, route
+}
+
+func selectorName(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+func stringLit(expr ast.Expr) string {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	return strings.Join(strings.Fields(printNode(fset, expr)), " ")
+}
+
+// ListRoutesTool returns the ListRoutes tool, which shows the HTTP routes registered
+// in the Go files of fsys as a table of method, path, handler and middleware
+func ListRoutesTool(fsys *FS) Tool {
+	return New("ListRoutes",
+		"Lists the HTTP routes registered with gorilla/mux or net/http: method, path, handler and middleware chain",
+		llm.NoInputSchema,
+		func(ctx context.Context, _ string) (string, error) {
+			routes, skipped, err := fsys.Routes()
+			if err != nil {
+				return "", err
+			}
+			if len(routes) == 0 {
+				return "No HTTP route registrations found" + skippedNote(skipped), nil
+			}
+
+			var b strings.Builder
+			b.WriteString("| Method | Path | Handler | Middleware | Source |\n")
+			b.WriteString("|---|---|---|---|---|\n")
+			for _, r := range routes {
+				method := r.Method
+				if method == "" {
+					method = "ANY"
+				}
+				middleware := strings.Join(r.Middleware, " -> ")
+				if middleware == "" {
+					middleware = "-"
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s:%d |\n", method, r.Path, r.Handler, middleware, r.File, r.Line)
+			}
+			return b.String() + skippedNote(skipped), nil
+		})
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// routeLines formats routes as "METHOD path handler middleware" for comparison
+func routeLines(routes []Route) []string {
+	var lines []string
+	for _, r := range routes {
+		lines = append(lines, fmt.Sprintf("%s %s %s [%s]", r.Method, r.Path, r.Handler, strings.Join(r.Middleware, " ")))
+	}
+	return lines
+}
+
+func checkRoutes(t *testing.T, fsys *FS, want []string) {
+	t.Helper()
+	routes, skipped, err := fsys.Routes()
+	if err != nil {
+		t.Fatalf("Routes: %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("skipped = %v", skipped)
+	}
+	got := routeLines(routes)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("routes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRoutesSampleServer(t *testing.T) {
+	fsys, err := OpenFS("../../03-api-server-react/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	const middleware = "[middleware.LoggingMiddleware middleware.AuthMiddleware]"
+	checkRoutes(t, fsys, []string{
+		"GET /api/users handler.GetUsers " + middleware,
+		"GET /api/users/{id} handler.GetUser " + middleware,
+		"POST /api/users handler.CreateUser " + middleware,
+		"GET /api/products handler.GetProducts " + middleware,
+		"GET /api/products/{id} handler.GetProduct " + middleware,
+		"POST /api/products handler.CreateProduct " + middleware,
+		"PATCH /api/products/{id}/stock handler.UpdateProductStock " + middleware,
+	})
+}
+
+func TestRoutesRouterCycle(t *testing.T) {
+	fsys := NewFS(fstest.MapFS{"main.go": {Data: []byte(`package main
+
+func main() {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	r = api.PathPrefix("/v1").Subrouter()
+	r.HandleFunc("/x", h)
+}
+`)}}, "data")
+
+	checkRoutes(t, fsys, []string{" /api/v1/x h []"})
+}
+
+func TestRoutesFollowsHelpers(t *testing.T) {
+	fsys := NewFS(fstest.MapFS{
+		"main.go": {Data: []byte(`package main
+
+func main() {
+	r := mux.NewRouter()
+	r.Use(logging)
+	api := r.PathPrefix("/api").Subrouter()
+	s := &server{}
+	s.routes(api)
+	http.ListenAndServe(":8080", r)
+}
+`)},
+		"routes.go": {Data: []byte(`package main
+
+type server struct{}
+
+func (s *server) routes(r *mux.Router) {
+	r.Use(auth)
+	r.HandleFunc("/users", s.users).Methods("GET")
+	nested(r)
+}
+
+func nested(m *mux.Router) {
+	m.HandleFunc("/health", health)
+}
+`)},
+	}, "data")
+
+	checkRoutes(t, fsys, []string{
+		"GET /api/users s.users [logging auth]",
+		" /api/health health [logging auth]",
+	})
+}
+
+func TestRoutesSkipsSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "x.go"), []byte("package x\n\nfunc init() { http.HandleFunc(\"/outside\", h) }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() { http.HandleFunc(\"GET /inside\", h) }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "x.go"), filepath.Join(dir, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	checkRoutes(t, fsys, []string{"GET /inside h []"})
+}
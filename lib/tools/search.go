@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultMaxMatches caps the results of SearchFiles
+	DefaultMaxMatches = 100
+	// maxMatchLine cuts long matched lines such as minified code
+	maxMatchLine = 200
+)
+
+// Match is a line found by Search
+type Match struct {
+	Path string
+	Line int
+	Text string
+}
+
+// Search returns the lines of files in the root matching re. When glob is set, only
+// files whose path or base name match it are searched. At most max matches are
+// returned (0 for no limit) and truncated reports that more exist. Hidden directories,
+// binary files, symlinks and files that cannot be read are skipped.
+func (f *FS) Search(re *regexp.Regexp, glob string, max int) (matches []Match, truncated bool, err error) {
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, false, fmt.Errorf("invalid glob %s: %w", glob, err)
+	}
+
+	errLimit := errors.New("limit reached")
+	err = fs.WalkDir(f.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || glob != "" && !matchGlob(glob, p) {
+			return nil
+		}
+
+		// One unreadable file should not make the whole tree unsearchable
+		content, err := fs.ReadFile(f.fsys, p)
+		if err != nil {
+			return nil
+		}
+		if bytes.IndexByte(content[:min(len(content), 512)], 0) >= 0 {
+			return nil
+		}
+
+		for i, line := range strings.Split(string(content), "\n") {
+			if !re.MatchString(line) {
+				continue
+			}
+			if max > 0 && len(matches) == max {
+				return errLimit
+			}
+			if len(line) > maxMatchLine {
+				line = line[:maxMatchLine] + "..."
+			}
+			matches = append(matches, Match{Path: p, Line: i + 1, Text: strings.TrimSpace(line)})
+		}
+		return nil
+	})
+	if errors.Is(err, errLimit) {
+		return matches, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to search files: %w", err)
+	}
+	return matches, false, nil
+}
+
+func matchGlob(glob, p string) bool {
+	if ok, _ := path.Match(glob, p); ok {
+		return true
+	}
+	ok, _ := path.Match(glob, path.Base(p))
+	return ok
+}
+
+var searchFilesSchema = json.RawMessage(`{"type":"object","properties":{` +
+	`"pattern":{"type":"string","description":"Go regular expression matched against each line, e.g. AuthMiddleware"},` +
+	`"glob":{"type":"string","description":"Only search files whose path or name match this glob, e.g. *.go"}},` +
+	`"required":["pattern"]}`)
+
+// SearchFilesTool returns the SearchFiles tool, which greps fsys for a regular expression.
+// Results are capped at maxMatches.
+func SearchFilesTool(fsys *FS, maxMatches int) Tool {
+	return New("SearchFiles",
+		"Searches all files for lines matching a regular expression and returns them as path:line: text",
+		searchFilesSchema,
+		func(ctx context.Context, input string) (string, error) {
+			var in struct {
+				Pattern string `json:"pattern"`
+				Glob    string `json:"glob"`
+			}
+			// A bare pattern searches every file
+			if strings.HasPrefix(strings.TrimSpace(input), "{") {
+				if err := json.Unmarshal([]byte(input), &in); err != nil {
+					return "", fmt.Errorf("invalid input: %w", err)
+				}
+			} else {
+				in.Pattern = input
+			}
+			if in.Pattern == "" {
+				return "", errors.New("a pattern is required")
+			}
+
+			re, err := regexp.Compile(in.Pattern)
+			if err != nil {
+				return "", fmt.Errorf("invalid pattern: %w", err)
+			}
+			matches, truncated, err := fsys.Search(re, in.Glob, maxMatches)
+			if err != nil {
+				return "", err
+			}
+			if len(matches) == 0 {
+				return fmt.Sprintf("No matches for %s", in.Pattern), nil
+			}
+
+			var b strings.Builder
+			for _, m := range matches {
+				fmt.Fprintf(&b, "%s:%d: %s\n", m.Path, m.Line, m.Text)
+			}
+			if truncated {
+				fmt.Fprintf(&b, "[results capped at %d matches; narrow the pattern or glob]\n", maxMatches)
+			}
+			return b.String(), nil
+		})
+}
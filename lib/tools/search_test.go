@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSearchSkipsSymlinkOutsideRoot(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("needle outside\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hay\nneedle inside\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "etc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt")); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	matches, truncated, err := fsys.Search(regexp.MustCompile("needle"), "", 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if truncated || len(matches) != 1 || matches[0] != (Match{Path: "a.txt", Line: 2, Text: "needle inside"}) {
+		t.Errorf("matches = %+v, want only the match inside the root", matches)
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("x\nx\nx\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := OpenFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	matches, truncated, err := fsys.Search(regexp.MustCompile("x"), "*.go", 2)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if !truncated || len(matches) != 2 || matches[1].Path != "a.go" {
+		t.Errorf("matches, truncated = %+v, %v, want two matches in a.go and truncated", matches, truncated)
+	}
+}
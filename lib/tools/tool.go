@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Tool is an action the agent can take. Input is the Action Input text, or in
+// native mode the tool input flattened with llm.InputText.
+type Tool interface {
+	Name() string
+	Description() string
+	InputSchema() json.RawMessage
+	// Execute returns the observation. Errors are reported to the model so it can recover.
+	Execute(ctx context.Context, input string) (string, error)
+}
+
+// ExecuteFunc is the body of a tool built with New
+type ExecuteFunc func(ctx context.Context, input string) (string, error)
+
+// New returns a tool backed by fn
+func New(name, description string, schema json.RawMessage, fn ExecuteFunc) Tool {
+	return &funcTool{name: name, description: description, schema: schema, fn: fn}
+}
+
+type funcTool struct {
+	name        string
+	description string
+	schema      json.RawMessage
+	fn          ExecuteFunc
+}
+
+func (t *funcTool) Name() string                 { return t.name }
+func (t *funcTool) Description() string          { return t.description }
+func (t *funcTool) InputSchema() json.RawMessage { return t.schema }
+
+func (t *funcTool) Execute(ctx context.Context, input string) (string, error) {
+	return t.fn(ctx, input)
+}
@@ -1,6 +1,148 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Message is one turn of the conversation. Plain text turns use Content;
+// turns carrying tool calls or tool results use Blocks.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string         `json:"role"`
+	Content string         `json:"-"`
+	Blocks  []ContentBlock `json:"-"`
+}
+
+// ContentBlock is a single Anthropic message content block
+type ContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+
+	// thinking and redacted_thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+
+	// CacheControl marks the end of a cacheable prompt prefix
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// CacheControl is an Anthropic prompt caching breakpoint
+type CacheControl struct {
+	Type string `json:"type"`
+}
+
+// EphemeralCache is the only cache type supported by Anthropic models
+var EphemeralCache = &CacheControl{Type: "ephemeral"}
+
+// Usage is the token usage reported in Anthropic responses and stream events
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+// StreamEvent is one event of a streaming Anthropic response, sent as a server-sent
+// event by the Messages API and as a response stream chunk by Bedrock
+type StreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string `json:"model"`
+		Usage Usage  `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage Usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Content block types
+const (
+	BlockText             = "text"
+	BlockToolUse          = "tool_use"
+	BlockToolResult       = "tool_result"
+	BlockThinking         = "thinking"
+	BlockRedactedThinking = "redacted_thinking"
+)
+
+type messageJSON struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// MarshalJSON encodes content as a string, or as a block array when Blocks is set
+func (m Message) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+	if len(m.Blocks) > 0 {
+		content = m.Blocks
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{Role: m.Role, Content: raw})
+}
+
+// UnmarshalJSON accepts content either as a string or as a block array
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var msg messageJSON
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = Message{Role: msg.Role}
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil
+	}
+	switch msg.Content[0] {
+	case '"':
+		return json.Unmarshal(msg.Content, &m.Content)
+	case '[':
+		return json.Unmarshal(msg.Content, &m.Blocks)
+	default:
+		return fmt.Errorf("unexpected message content: %s", msg.Content)
+	}
+}
+
+// WithCacheBreakpoints returns copies of system and messages with cache_control set
+// on the last system block and the last block of the final message
+func WithCacheBreakpoints(system []ContentBlock, messages []Message) ([]ContentBlock, []Message) {
+	if len(system) > 0 {
+		system = append([]ContentBlock(nil), system...)
+		system[len(system)-1].CacheControl = EphemeralCache
+	}
+
+	if len(messages) == 0 {
+		return system, messages
+	}
+
+	last := messages[len(messages)-1]
+	blocks := append([]ContentBlock(nil), last.Blocks...)
+	if len(blocks) == 0 {
+		if last.Content == "" {
+			return system, messages
+		}
+		blocks = []ContentBlock{{Type: BlockText, Text: last.Content}}
+	}
+	blocks[len(blocks)-1].CacheControl = EphemeralCache
+
+	messages = append([]Message(nil), messages...)
+	messages[len(messages)-1] = Message{Role: last.Role, Blocks: blocks}
+	return system, messages
 }
@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded matches every *BudgetError with errors.Is
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget limits a run. Zero values disable the corresponding limit.
+type Budget struct {
+	MaxTokens int
+	// MaxCost is in USD, priced by the ledger's price table
+	MaxCost  float64
+	Deadline time.Time
+}
+
+// BudgetError reports which limit ran out
+type BudgetError struct {
+	// Limit is "tokens", "cost" or "deadline"
+	Limit  string
+	Used   Tokens
+	Cost   float64
+	Budget Budget
+}
+
+func (e *BudgetError) Error() string {
+	switch e.Limit {
+	case "tokens":
+		return fmt.Sprintf("%v: used %d of %d tokens", ErrBudgetExceeded, e.Used.Total(), e.Budget.MaxTokens)
+	case "cost":
+		return fmt.Sprintf("%v: spent $%.4f of $%.4f", ErrBudgetExceeded, e.Cost, e.Budget.MaxCost)
+	default:
+		return fmt.Sprintf("%v: deadline %s passed", ErrBudgetExceeded, e.Budget.Deadline.Format(time.TimeOnly))
+	}
+}
+
+func (e *BudgetError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// budgetScope is one budget on the context chain. Usage is measured from the
+// ledger totals at the time the budget was set.
+type budgetScope struct {
+	budget   Budget
+	ledger   *Ledger
+	baseline Tokens
+	baseCost float64
+	parent   *budgetScope
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context enforcing b on everything run under it, including nested
+// agents. Budgets nest: an inner budget can only tighten the outer ones. A ledger is added
+// to the context if none is present. A deadline also cancels the returned context.
+func WithBudget(ctx context.Context, b Budget) (context.Context, context.CancelFunc) {
+	ledger := FromContext(ctx)
+	if ledger == nil {
+		ledger = NewLedger(DefaultPrices())
+		ctx = WithLedger(ctx, ledger)
+	}
+
+	parent, _ := ctx.Value(budgetKey{}).(*budgetScope)
+	scope := &budgetScope{
+		budget:   b,
+		ledger:   ledger,
+		baseline: ledger.Total(),
+		baseCost: ledger.Cost(),
+		parent:   parent,
+	}
+	ctx = context.WithValue(ctx, budgetKey{}, scope)
+
+	if b.Deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.Deadline)
+}
+
+// CheckBudget returns a *BudgetError if any budget on ctx has run out
+func CheckBudget(ctx context.Context) error {
+	scope, _ := ctx.Value(budgetKey{}).(*budgetScope)
+	for ; scope != nil; scope = scope.parent {
+		if err := scope.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *budgetScope) check() error {
+	total := s.ledger.Total()
+	used := Tokens{
+		Calls:      total.Calls - s.baseline.Calls,
+		Input:      total.Input - s.baseline.Input,
+		Output:     total.Output - s.baseline.Output,
+		CacheRead:  total.CacheRead - s.baseline.CacheRead,
+		CacheWrite: total.CacheWrite - s.baseline.CacheWrite,
+	}
+	cost := s.ledger.Cost() - s.baseCost
+	err := &BudgetError{Used: used, Cost: cost, Budget: s.budget}
+
+	switch {
+	case s.budget.MaxTokens > 0 && used.Total() >= s.budget.MaxTokens:
+		err.Limit = "tokens"
+	case s.budget.MaxCost > 0 && cost >= s.budget.MaxCost:
+		err.Limit = "cost"
+	case !s.budget.Deadline.IsZero() && !time.Now().Before(s.budget.Deadline):
+		err.Limit = "deadline"
+	default:
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+func record(ctx context.Context, tokens int) {
+	FromContext(ctx).Record("agent", &llm.Result{Model: "claude-haiku-4-5", InputTokens: tokens})
+}
+
+func TestCheckBudgetTokens(t *testing.T) {
+	ctx, cancel := WithBudget(context.Background(), Budget{MaxTokens: 100})
+	defer cancel()
+
+	record(ctx, 60)
+	if err := CheckBudget(ctx); err != nil {
+		t.Fatalf("CheckBudget() = %v under the limit", err)
+	}
+	record(ctx, 40)
+	err := CheckBudget(ctx)
+	var budgetErr *BudgetError
+	if !errors.Is(err, ErrBudgetExceeded) || !errors.As(err, &budgetErr) {
+		t.Fatalf("CheckBudget() = %v, want a BudgetError", err)
+	}
+	if budgetErr.Limit != "tokens" || budgetErr.Used.Total() != 100 {
+		t.Errorf("BudgetError = %+v, want tokens with 100 used", budgetErr)
+	}
+}
+
+func TestCheckBudgetNested(t *testing.T) {
+	outer, cancel := WithBudget(context.Background(), Budget{MaxTokens: 1000})
+	defer cancel()
+	record(outer, 900)
+
+	// The inner budget counts from the usage at the time it was set
+	inner, cancelInner := WithBudget(outer, Budget{MaxTokens: 200})
+	defer cancelInner()
+	if err := CheckBudget(inner); err != nil {
+		t.Fatalf("CheckBudget() = %v for a fresh inner budget", err)
+	}
+
+	// 150 tokens fit the inner budget but use up the outer one
+	record(inner, 150)
+	var budgetErr *BudgetError
+	if err := CheckBudget(inner); !errors.As(err, &budgetErr) || budgetErr.Budget.MaxTokens != 1000 {
+		t.Errorf("CheckBudget() = %v, want the outer budget exceeded", err)
+	}
+}
+
+func TestCheckBudgetCost(t *testing.T) {
+	// claude-haiku-4-5 input costs $1 per million tokens
+	ctx, cancel := WithBudget(context.Background(), Budget{MaxCost: 0.001})
+	defer cancel()
+
+	record(ctx, 2000)
+	var budgetErr *BudgetError
+	if err := CheckBudget(ctx); !errors.As(err, &budgetErr) || budgetErr.Limit != "cost" {
+		t.Errorf("CheckBudget() = %v, want the cost budget exceeded", err)
+	}
+}
@@ -0,0 +1,61 @@
+package usage
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/toumakido/reAct/lib/envvar"
+)
+
+// Flags holds ledger and budget settings parsed from the command line
+type Flags struct {
+	PricesPath string
+	MaxTokens  int
+	MaxCost    float64
+	Timeout    time.Duration
+	// err reports budget environment variables that do not parse
+	err error
+}
+
+// RegisterFlags registers -prices, -max-tokens-total, -max-cost and -timeout on fs,
+// defaulting from REACT_PRICES, REACT_MAX_TOKENS, REACT_MAX_COST and REACT_TIMEOUT
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	maxTokens, tokensErr := envvar.Lookup("REACT_MAX_TOKENS", 0, strconv.Atoi)
+	maxCost, costErr := envvar.Lookup("REACT_MAX_COST", 0, envvar.ParseFloat)
+	timeout, timeoutErr := envvar.Lookup("REACT_TIMEOUT", 0, time.ParseDuration)
+	f.err = errors.Join(tokensErr, costErr, timeoutErr)
+	fs.StringVar(&f.PricesPath, "prices", os.Getenv("REACT_PRICES"), "JSON price table (USD per million tokens) merged over the defaults (env REACT_PRICES)")
+	fs.IntVar(&f.MaxTokens, "max-tokens-total", maxTokens, "Stop the run after this many tokens across all agents, 0 for no limit (env REACT_MAX_TOKENS)")
+	fs.Float64Var(&f.MaxCost, "max-cost", maxCost, "Stop the run after this cost in USD, 0 for no limit (env REACT_MAX_COST)")
+	fs.DurationVar(&f.Timeout, "timeout", timeout, "Stop the run after this wall-clock time, 0 for no limit (env REACT_TIMEOUT)")
+	return f
+}
+
+// NewLedger creates a ledger priced with the configured table
+func (f *Flags) NewLedger() (*Ledger, error) {
+	if f.PricesPath == "" {
+		return NewLedger(DefaultPrices()), nil
+	}
+	prices, err := LoadPrices(f.PricesPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewLedger(prices), nil
+}
+
+// Budget returns the configured limits, starting the timeout now. It fails when a
+// budget environment variable does not parse, rather than running without the limit.
+func (f *Flags) Budget() (Budget, error) {
+	if f.err != nil {
+		return Budget{}, f.err
+	}
+	b := Budget{MaxTokens: f.MaxTokens, MaxCost: f.MaxCost}
+	if f.Timeout > 0 {
+		b.Deadline = time.Now().Add(f.Timeout)
+	}
+	return b, nil
+}
@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlagsBudget(t *testing.T) {
+	t.Setenv("REACT_MAX_TOKENS", "1000")
+	t.Setenv("REACT_MAX_COST", "0.5")
+	t.Setenv("REACT_TIMEOUT", "1m")
+
+	f := RegisterFlags(flag.NewFlagSet("test", flag.ContinueOnError))
+	b, err := f.Budget()
+	if err != nil {
+		t.Fatalf("Budget() error = %v", err)
+	}
+	if b.MaxTokens != 1000 || b.MaxCost != 0.5 {
+		t.Errorf("Budget() = %+v, want 1000 tokens and 0.5 USD", b)
+	}
+	if left := time.Until(b.Deadline); left <= 0 || left > time.Minute {
+		t.Errorf("Budget() deadline in %v, want within 1m", left)
+	}
+}
+
+func TestFlagsBudgetRejectsMalformedEnv(t *testing.T) {
+	t.Setenv("REACT_MAX_COST", "abc")
+	t.Setenv("REACT_TIMEOUT", "soon")
+
+	f := RegisterFlags(flag.NewFlagSet("test", flag.ContinueOnError))
+	_, err := f.Budget()
+	if err == nil {
+		t.Fatal("Budget() = nil error, want malformed REACT_MAX_COST and REACT_TIMEOUT reported")
+	}
+	for _, key := range []string{"REACT_MAX_COST", "REACT_TIMEOUT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Budget() error = %v, want it to name %s", err, key)
+		}
+	}
+}
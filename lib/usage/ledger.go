@@ -0,0 +1,193 @@
+// Package usage totals token usage and cost across a run, including nested subagents
+package usage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+// Tokens is an aggregate of token counts
+type Tokens struct {
+	Calls      int
+	Input      int
+	Output     int
+	CacheRead  int
+	CacheWrite int
+}
+
+// Add accumulates o into t
+func (t *Tokens) Add(o Tokens) {
+	t.Calls += o.Calls
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheRead += o.CacheRead
+	t.CacheWrite += o.CacheWrite
+}
+
+// Total returns all tokens counted against the context window and bill
+func (t Tokens) Total() int {
+	return t.Input + t.Output + t.CacheRead + t.CacheWrite
+}
+
+// FromResult returns the token counts of a single model call
+func FromResult(r *llm.Result) Tokens {
+	return Tokens{
+		Calls:      1,
+		Input:      r.InputTokens,
+		Output:     r.OutputTokens,
+		CacheRead:  r.CacheReadInputTokens,
+		CacheWrite: r.CacheCreationInputTokens,
+	}
+}
+
+type entryKey struct {
+	agent string
+	model string
+}
+
+// Ledger records usage per agent and per model. It is safe for concurrent use,
+// and a nil *Ledger ignores records.
+type Ledger struct {
+	prices PriceTable
+
+	mu      sync.Mutex
+	entries map[entryKey]*Tokens
+}
+
+// NewLedger creates a ledger that prices usage with the given table
+func NewLedger(prices PriceTable) *Ledger {
+	return &Ledger{
+		prices:  prices,
+		entries: make(map[entryKey]*Tokens),
+	}
+}
+
+// Record adds the usage of one model call made by agent
+func (l *Ledger) Record(agent string, r *llm.Result) {
+	if l == nil || r == nil {
+		return
+	}
+	model := r.Model
+	if model == "" {
+		model = "unknown"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := entryKey{agent: agent, model: model}
+	t, ok := l.entries[key]
+	if !ok {
+		t = &Tokens{}
+		l.entries[key] = t
+	}
+	t.Add(FromResult(r))
+}
+
+// Total returns the usage summed over every agent and model
+func (l *Ledger) Total() Tokens {
+	var total Tokens
+	for _, t := range l.group(func(entryKey) string { return "" }) {
+		total.Add(t)
+	}
+	return total
+}
+
+// ByAgent returns the usage summed per agent
+func (l *Ledger) ByAgent() map[string]Tokens {
+	return l.group(func(k entryKey) string { return k.agent })
+}
+
+// ByModel returns the usage summed per model
+func (l *Ledger) ByModel() map[string]Tokens {
+	return l.group(func(k entryKey) string { return k.model })
+}
+
+// Cost returns the total cost in USD. Models missing from the price table cost nothing.
+func (l *Ledger) Cost() float64 {
+	var cost float64
+	for model, t := range l.ByModel() {
+		if p, ok := l.prices.Lookup(model); ok {
+			cost += p.Cost(t)
+		}
+	}
+	return cost
+}
+
+// PrintSummary writes the per-agent, per-model and total usage with cost
+func (l *Ledger) PrintSummary(w io.Writer) {
+	if l == nil {
+		return
+	}
+
+	fmt.Fprintln(w, "=== Usage Summary ===")
+
+	fmt.Fprintln(w, "By agent:")
+	byAgent := l.ByAgent()
+	for _, name := range sortedKeys(byAgent) {
+		fmt.Fprintf(w, "  %s: %s\n", name, byAgent[name])
+	}
+
+	fmt.Fprintln(w, "By model:")
+	byModel := l.ByModel()
+	for _, model := range sortedKeys(byModel) {
+		t := byModel[model]
+		if p, ok := l.prices.Lookup(model); ok {
+			fmt.Fprintf(w, "  %s: %s, Cost: $%.4f\n", model, t, p.Cost(t))
+		} else {
+			fmt.Fprintf(w, "  %s: %s, Cost: unknown (no price)\n", model, t)
+		}
+	}
+
+	fmt.Fprintf(w, "Total: %s, Cost: $%.4f\n", l.Total(), l.Cost())
+}
+
+// String formats the token counts for the summary
+func (t Tokens) String() string {
+	return fmt.Sprintf("Calls: %d, Input: %d, Output: %d, Cache Read: %d, Cache Write: %d",
+		t.Calls, t.Input, t.Output, t.CacheRead, t.CacheWrite)
+}
+
+func (l *Ledger) group(keyOf func(entryKey) string) map[string]Tokens {
+	groups := make(map[string]Tokens)
+	if l == nil {
+		return groups
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for k, t := range l.entries {
+		g := groups[keyOf(k)]
+		g.Add(*t)
+		groups[keyOf(k)] = g
+	}
+	return groups
+}
+
+func sortedKeys(m map[string]Tokens) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type ledgerKey struct{}
+
+// WithLedger returns a context carrying the ledger, so nested agents record into it
+func WithLedger(ctx context.Context, l *Ledger) context.Context {
+	return context.WithValue(ctx, ledgerKey{}, l)
+}
+
+// FromContext returns the ledger carried by ctx, or nil
+func FromContext(ctx context.Context) *Ledger {
+	l, _ := ctx.Value(ledgerKey{}).(*Ledger)
+	return l
+}
@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/toumakido/reAct/lib/llm"
+)
+
+func TestLedgerAggregation(t *testing.T) {
+	l := NewLedger(DefaultPrices())
+	l.Record("Main Agent", &llm.Result{Model: "claude-sonnet-4-5", InputTokens: 1000, OutputTokens: 100, CacheReadInputTokens: 2000})
+	l.Record("Main Agent", &llm.Result{Model: "claude-sonnet-4-5", InputTokens: 500, OutputTokens: 50})
+	l.Record("Subagent", &llm.Result{Model: "claude-haiku-4-5", InputTokens: 4000, OutputTokens: 400, CacheCreationInputTokens: 1000})
+	l.Record("Subagent", &llm.Result{InputTokens: 10})
+	l.Record("Subagent", nil)
+
+	byAgent := l.ByAgent()
+	if got := byAgent["Main Agent"]; got != (Tokens{Calls: 2, Input: 1500, Output: 150, CacheRead: 2000}) {
+		t.Errorf("Main Agent = %+v", got)
+	}
+	if got := byAgent["Subagent"]; got != (Tokens{Calls: 2, Input: 4010, Output: 400, CacheWrite: 1000}) {
+		t.Errorf("Subagent = %+v", got)
+	}
+
+	byModel := l.ByModel()
+	if len(byModel) != 3 || byModel["unknown"].Input != 10 {
+		t.Errorf("ByModel = %+v, want sonnet, haiku and unknown", byModel)
+	}
+	if total := l.Total(); total.Calls != 4 || total.Total() != 1500+150+2000+4010+400+1000 {
+		t.Errorf("Total = %+v", total)
+	}
+
+	// Sonnet: 1500*3 + 150*15 + 2000*0.30; Haiku: 4000*1 + 400*5 + 1000*1.25; unknown is free
+	want := (1500*3 + 150*15 + 2000*0.30 + 4000*1 + 400*5 + 1000*1.25) / 1_000_000
+	if got := l.Cost(); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Cost = %f, want %f", got, want)
+	}
+
+	var summary strings.Builder
+	l.PrintSummary(&summary)
+	if !strings.Contains(summary.String(), "unknown: Calls: 1, Input: 10") || !strings.Contains(summary.String(), "Cost: unknown (no price)") {
+		t.Errorf("summary does not show the unpriced model:\n%s", summary.String())
+	}
+}
+
+func TestNilLedger(t *testing.T) {
+	var l *Ledger
+	l.Record("agent", &llm.Result{InputTokens: 1})
+	if total := l.Total(); total != (Tokens{}) || l.Cost() != 0 {
+		t.Errorf("nil ledger Total, Cost = %+v, %f, want zero", total, l.Cost())
+	}
+}
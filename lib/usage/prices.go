@@ -0,0 +1,77 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Price is the USD cost per million tokens of a model
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Cost converts token counts to USD
+func (p Price) Cost(t Tokens) float64 {
+	return (float64(t.Input)*p.Input +
+		float64(t.Output)*p.Output +
+		float64(t.CacheRead)*p.CacheRead +
+		float64(t.CacheWrite)*p.CacheWrite) / 1_000_000
+}
+
+// PriceTable maps a model name to its price. Keys match any model ID that contains
+// them, so "claude-haiku-4-5" covers "global.anthropic.claude-haiku-4-5-20251001-v1:0".
+type PriceTable map[string]Price
+
+// DefaultPrices returns list prices of the Claude models used by the examples
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.10, CacheWrite: 1.25},
+		"claude-sonnet-4-5": {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+		"claude-opus-4-1":   {Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	}
+}
+
+// Lookup returns the price of model, preferring the longest matching key
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+
+	var (
+		best  Price
+		found string
+	)
+	for key, p := range t {
+		if strings.Contains(model, key) && len(key) > len(found) {
+			best, found = p, key
+		}
+	}
+	return best, found != ""
+}
+
+// LoadPrices reads a JSON price table, e.g. {"my-model": {"input": 1, "output": 2}},
+// and merges it over DefaultPrices
+func LoadPrices(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var custom PriceTable
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+
+	prices := DefaultPrices()
+	for model, p := range custom {
+		prices[model] = p
+	}
+	return prices, nil
+}
@@ -0,0 +1,76 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPriceTableLookup(t *testing.T) {
+	prices := DefaultPrices()
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"claude-sonnet-4-5", "claude-sonnet-4-5"},
+		{"global.anthropic.claude-sonnet-4-5-20250929-v1:0", "claude-sonnet-4-5"},
+		{"us.anthropic.claude-sonnet-4-20250514-v1:0", "claude-sonnet-4"},
+		{"global.anthropic.claude-haiku-4-5-20251001-v1:0", "claude-haiku-4-5"},
+		{"us.anthropic.claude-3-5-haiku-20241022-v1:0", "claude-3-5-haiku"},
+		{"llama-3-70b", ""},
+	}
+	for _, tt := range tests {
+		got, ok := prices.Lookup(tt.model)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Lookup(%q) = %+v, want no price", tt.model, got)
+			}
+			continue
+		}
+		if !ok || got != prices[tt.want] {
+			t.Errorf("Lookup(%q) = %+v, %v, want the price of %s", tt.model, got, ok, tt.want)
+		}
+	}
+}
+
+func TestLookupPrefersLongestKey(t *testing.T) {
+	// claude-sonnet-4 is a substring of claude-sonnet-4-5 model IDs, so the longer key must win
+	prices := PriceTable{
+		"claude-sonnet-4":   {Input: 1},
+		"claude-sonnet-4-5": {Input: 2},
+	}
+	for i := 0; i < 20; i++ {
+		if got, _ := prices.Lookup("global.anthropic.claude-sonnet-4-5-20250929-v1:0"); got.Input != 2 {
+			t.Fatalf("Lookup matched the shorter key: %+v", got)
+		}
+	}
+}
+
+func TestLoadPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	custom := `{"my-model": {"input": 1, "output": 2}, "claude-haiku-4-5": {"input": 0.5, "output": 2.5}}`
+	if err := os.WriteFile(path, []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := LoadPrices(path)
+	if err != nil {
+		t.Fatalf("LoadPrices: %v", err)
+	}
+	if got := prices["my-model"]; got != (Price{Input: 1, Output: 2}) {
+		t.Errorf("my-model = %+v", got)
+	}
+	if got := prices["claude-haiku-4-5"]; got != (Price{Input: 0.5, Output: 2.5}) {
+		t.Errorf("claude-haiku-4-5 = %+v, want the custom price to replace the default", got)
+	}
+	if got := prices["claude-sonnet-4-5"]; got != DefaultPrices()["claude-sonnet-4-5"] {
+		t.Errorf("claude-sonnet-4-5 = %+v, want the default kept", got)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrices(path); err == nil {
+		t.Errorf("LoadPrices accepted invalid JSON")
+	}
+}
@@ -2,13 +2,10 @@ package codeanalysis
 
 import (
 	"context"
-	"fmt"
-	"regexp"
-	"strings"
 
-	"github.com/toumakido/reAct/lib/bedrock"
+	"github.com/toumakido/reAct/lib/llm"
+	"github.com/toumakido/reAct/lib/react"
 	"github.com/toumakido/reAct/lib/tools"
-	"github.com/toumakido/reAct/lib/types"
 )
 
 const systemPrompt = `You are a code analysis assistant that reads Go source files and answers questions about API server implementations.
@@ -19,29 +16,14 @@ IMPORTANT: Always respond in English. All your Thoughts, Actions, and Final Answ
 
 You MUST use the Available Tools to retrieve actual information from the file system. NEVER make assumptions or invent information about the codebase. All your reasoning and answers must be based on information obtained through tool usage.
 
-## Available Tools
-
-### 1. ListFiles
-**Function**: Displays all files and directories under the data directory in tree format
-**Usage**:
-  Action: ListFiles
-  Action Input: ListFiles
-**When to Use**: When you need to understand the project structure or check which files exist
-
-### 2. ReadFile
-**Function**: Reads the contents of a specified Go source file
-**Usage**:
-  Action: ReadFile
-  Action Input: [relative path from data directory]
-**Input Examples**: cmd/api/main.go, internal/handler/user.go, pkg/middleware/auth.go
-**When to Use**: When you need to examine code in a specific file
+{{tools}}
 
 ## Your Action Flow
 
 **Step 1: Reasoning and Action Decision**
 Think about what to do next and output these 3 lines:
 Thought: [What you want to know and why you're using this tool]
-Action: [ListFiles or ReadFile]
+Action: [one of the tool names above]
 Action Input: [Input to pass to the tool]
 
 **IMPORTANT**: After outputting these 3 lines, you MUST stop there. NEVER generate Observation yourself.
@@ -57,7 +39,7 @@ After receiving the Observation, either return to Step 1 or provide a final answ
 [Turn 1 - Your Output]
 Thought: I need to check the directory structure first to understand the project layout.
 Action: ListFiles
-Action Input: ListFiles
+Action Input: none
 
 [System Response]
 Observation: [The system will return the actual directory structure]
@@ -81,133 +63,86 @@ Once you have collected all necessary information, respond with this format:
 Thought: [Reason why you can answer]
 Final Answer: [Your complete and detailed answer to the user's question]`
 
-const maxIterations = 15
+const nativeSystemPrompt = `You are a code analysis assistant that reads Go source files and answers questions about API server implementations.
+
+IMPORTANT: Always respond in English.
+
+You MUST use the provided tools to retrieve actual information from the file system. NEVER make assumptions or invent information about the codebase. All your reasoning and answers must be based on information obtained through tool usage.
+
+- Use ListFiles first to understand the project structure.
+- Use ReadFile with a path relative to the data directory (e.g. cmd/api/main.go) to examine code.
+- Use ReadFileRange to page through large files instead of reading them whole.
+- Use SearchFiles to find where an identifier is defined or used instead of reading every file.
+- Use ListSymbols and GetSymbol to see the declared functions, methods and types and read the exact source of one of them.
+- Use ListRoutes to get the HTTP endpoints with their handlers and middleware instead of reading the router setup by hand.
+- Use QuerySymbol for cross-file questions such as which handlers call a service function or where a middleware is used.
+- If the Exec tool is available, use it to check your conclusions by building, vetting or testing the code.
+- When you have all necessary information, reply without calling any tool. Start that reply with "Final Answer:" followed by your complete and detailed answer.`
 
 // Config holds the configuration for the code analysis agent
 type Config struct {
 	MaxIterations int
+	Verbose       bool
+	// ToolMode selects text-parsed actions or native tool calling
+	ToolMode react.ToolMode
+	// MaxObservation and History bound the context used by file contents
+	MaxObservation int
+	History        react.History
+	// Exec adds the Exec tool with these limits. Nil leaves it out, since tests run
+	// code from the data directory.
+	Exec *tools.ExecConfig
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		MaxIterations: maxIterations,
+		MaxIterations: react.DefaultConfig().MaxIterations,
 	}
 }
 
-// RunAnalysis runs the ReAct loop for code analysis
-func RunAnalysis(ctx context.Context, client *bedrock.Client, question string, config Config) (string, error) {
-	messages := []types.Message{
-		{
-			Role:    "user",
-			Content: question,
-		},
+// NewAgent builds the code analysis agent on the shared ReAct engine, exploring fsys
+func NewAgent(client llm.LLM, fsys *tools.FS, config Config) *react.Agent {
+	agentConfig := react.DefaultConfig()
+	agentConfig.MaxIterations = config.MaxIterations
+	agentConfig.Verbose = config.Verbose
+	agentConfig.ToolMode = config.ToolMode
+	agentConfig.MaxObservation = config.MaxObservation
+	agentConfig.History = config.History
+
+	prompt := systemPrompt
+	if config.ToolMode == react.NativeMode {
+		prompt = nativeSystemPrompt
 	}
 
-	fmt.Println("=== Starting Code Analysis ReAct Agent ===")
-	fmt.Printf("Question: %s\n\n", question)
-
-	var finalAnswer string
-
-	for i := 0; i < config.MaxIterations; i++ {
-		fmt.Printf("--- Iteration %d ---\n", i+1)
-
-		result, err := client.InvokeModel(ctx, systemPrompt, messages)
-		if err != nil {
-			return "", fmt.Errorf("failed to invoke model: %w", err)
-		}
-
-		fmt.Println(result.Text)
-		fmt.Printf("\n[Token Usage] Input: %d, Output: %d, Total: %d\n\n",
-			result.InputTokens, result.OutputTokens, result.InputTokens+result.OutputTokens)
-
-		messages = append(messages, types.Message{
-			Role:    "assistant",
-			Content: result.Text,
-		})
-
-		if strings.Contains(result.Text, "Final Answer:") {
-			finalAnswer = extractFinalAnswer(result.Text)
-			fmt.Println("=== Agent Complete ===")
-			return finalAnswer, nil
-		}
-
-		action, actionInput, found := parseAction(result.Text)
-		if !found {
-			continue
-		}
-
-		observation := executeAction(action, actionInput)
-
-		messages = append(messages, types.Message{
-			Role:    "user",
-			Content: fmt.Sprintf("Observation: %s", observation),
-		})
+	registry := tools.NewRegistry(
+		tools.ListFilesTreeTool(fsys),
+		tools.ReadFileTool(fsys),
+		tools.ReadFileRangeTool(fsys, tools.DefaultMaxBytes),
+		tools.SearchFilesTool(fsys, tools.DefaultMaxMatches),
+		tools.ListSymbolsTool(fsys),
+		tools.GetSymbolTool(fsys),
+		tools.QuerySymbolTool(fsys),
+		tools.ListRoutesTool(fsys),
+	)
+	if config.Exec != nil {
+		registry.Register(tools.ExecTool(fsys, *config.Exec))
 	}
 
-	return "", fmt.Errorf("max iterations (%d) reached without final answer", config.MaxIterations)
-}
-
-func executeAction(action, actionInput string) string {
-	switch action {
-	case "ListFiles":
-		result, err := tools.ListFilesTree()
-		if err != nil {
-			return fmt.Sprintf("Error listing files: %v", err)
-		}
-		return result
-
-	case "ReadFile":
-		if actionInput == "" {
-			return "Error: ReadFile requires a filename as Action Input"
-		}
-		content, err := tools.ReadFile(actionInput)
-		if err != nil {
-			return fmt.Sprintf("Error reading file: %v", err)
-		}
-		return fmt.Sprintf("Content of %s:\n%s", actionInput, content)
-
-	default:
-		return fmt.Sprintf("Error: Unknown action '%s'. Available actions: ListFiles, ReadFile", action)
+	return &react.Agent{
+		Name:         "Code Analysis ReAct Agent",
+		SystemPrompt: registry.Prompt(prompt),
+		Tools:        registry,
+		Client:       client,
+		Config:       agentConfig,
 	}
 }
 
-func parseAction(response string) (action string, actionInput string, found bool) {
-	actionRegex := regexp.MustCompile(`(?i)Action:\s*(\w+)`)
-	actionMatch := actionRegex.FindStringSubmatch(response)
-	if len(actionMatch) < 2 {
-		return "", "", false
-	}
-	action = strings.TrimSpace(actionMatch[1])
-
-	actionInputRegex := regexp.MustCompile(`(?i)Action Input:\s*(.+?)(?:\n|$)`)
-	actionInputMatch := actionInputRegex.FindStringSubmatch(response)
-	if len(actionInputMatch) >= 2 {
-		actionInput = strings.TrimSpace(actionInputMatch[1])
+// RunAnalysis runs the ReAct loop for code analysis.
+// If the run exceeds its budget, the partial answer is returned with the error.
+func RunAnalysis(ctx context.Context, client llm.LLM, fsys *tools.FS, question string, config Config) (string, error) {
+	result, err := NewAgent(client, fsys, config).Run(ctx, question)
+	if result == nil {
+		return "", err
 	}
-
-	return action, actionInput, true
-}
-
-func extractFinalAnswer(response string) string {
-	lines := strings.Split(response, "\n")
-	inFinalAnswer := false
-	var answer []string
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "Final Answer:") {
-			inFinalAnswer = true
-			// Include the content after "Final Answer:" on the same line
-			content := strings.TrimSpace(strings.TrimPrefix(line, "Final Answer:"))
-			if content != "" {
-				answer = append(answer, content)
-			}
-			continue
-		}
-		if inFinalAnswer {
-			answer = append(answer, line)
-		}
-	}
-
-	return strings.TrimSpace(strings.Join(answer, "\n"))
+	return result.Answer, err
 }
@@ -0,0 +1,77 @@
+package codeanalysis
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/toumakido/reAct/lib/llmtest"
+	"github.com/toumakido/reAct/lib/react"
+	"github.com/toumakido/reAct/lib/tools"
+)
+
+var testFS = tools.NewFS(fstest.MapFS{
+	"cmd/api/main.go": {Data: []byte("package main\n\nfunc main() {}\n")},
+}, "data")
+
+func TestNewAgentRunsOnSharedEngine(t *testing.T) {
+	model := llmtest.New(
+		llmtest.Action("Read the entry point", "ReadFile", "cmd/api/main.go"),
+		llmtest.FinalAnswer("It is empty", "main does nothing"),
+	)
+	agent := NewAgent(model, testFS, DefaultConfig())
+	agent.Config.Output = io.Discard
+
+	result, err := agent.Run(context.Background(), "What does main do?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "main does nothing" {
+		t.Errorf("Answer = %q", result.Answer)
+	}
+
+	calls := model.Calls()
+	if strings.Contains(calls[0].SystemPrompt, "{{tools}}") || !strings.Contains(calls[0].SystemPrompt, "QuerySymbol") {
+		t.Errorf("system prompt does not list the registered tools")
+	}
+	observation := calls[1].Messages[2].Content
+	if !strings.HasPrefix(observation, "Observation: ") || !strings.Contains(observation, "func main() {}") {
+		t.Errorf("observation = %q, want the file content", observation)
+	}
+}
+
+func TestNewAgentNativeMode(t *testing.T) {
+	model := &llmtest.Model{}
+	model.Push(
+		llmtest.ToolUse("toolu_1", "ReadFile", `{"filename": "cmd/api/main.go"}`),
+		llmtest.Reply{Text: "Final Answer: main does nothing"},
+	)
+	config := DefaultConfig()
+	config.ToolMode = react.NativeMode
+	agent := NewAgent(model, testFS, config)
+	agent.Config.Output = io.Discard
+
+	result, err := agent.Run(context.Background(), "What does main do?")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Answer != "main does nothing" {
+		t.Errorf("Answer = %q", result.Answer)
+	}
+
+	first := model.Calls()[0]
+	if first.SystemPrompt != nativeSystemPrompt {
+		t.Errorf("native mode does not use the native system prompt")
+	}
+	var names []string
+	for _, spec := range first.Tools {
+		names = append(names, spec.Name)
+	}
+	want := []string{"ListFiles", "ReadFile", "ReadFileRange", "SearchFiles", "ListSymbols", "GetSymbol", "QuerySymbol", "ListRoutes"}
+	if !slices.Equal(names, want) {
+		t.Errorf("tool specs = %v, want %v", names, want)
+	}
+}